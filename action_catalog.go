@@ -0,0 +1,491 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultActionCatalog is the built-in catalog used when no catalog file is configured
+//
+//go:embed action_catalog.json
+var defaultActionCatalog []byte
+
+// catalogPlaceholderPattern matches {name} placeholders in command patterns and templates
+// Templates may type a placeholder as {name|number}, {name|integer} or {name|bool}
+var catalogPlaceholderPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)(?:\|([A-Za-z]+))?\}`)
+
+// ActionCatalogFile represents the on-disk action catalog
+type ActionCatalogFile struct {
+	Actions []ActionCatalogEntry `json:"actions"`
+}
+
+// ActionCatalogEntry maps a PLC command pattern to instant actions, an order template
+// or a recipe (a sequence of other order commands combined into one order)
+// The pattern captures parameters with {name}, e.g. "I:{inference_name}"
+// BusyPolicy overrides what happens while the robot executes an order (default: send for
+// instant actions, APP_ORDER_BUSY_POLICY for orders and recipes)
+type ActionCatalogEntry struct {
+	Name           string           `json:"name"`
+	Pattern        string           `json:"pattern"`
+	InstantActions []ActionTemplate `json:"instantActions,omitempty"`
+	Order          *OrderTemplate   `json:"order,omitempty"`
+	Sequence       []string         `json:"sequence,omitempty"`
+	BusyPolicy     BusyPolicy       `json:"busyPolicy,omitempty"`
+}
+
+// ActionTemplate describes a VDA5050 action; string values may reference captured parameters
+// A parameter value that is exactly one typed placeholder ("{x|number}") is converted to that type
+// StationParameter names the parameter that receives the command's "@station" as a pose
+type ActionTemplate struct {
+	ActionType        string            `json:"actionType"`
+	ActionDescription string            `json:"actionDescription,omitempty"`
+	BlockingType      string            `json:"blockingType"`
+	Parameters        []ActionParameter `json:"parameters,omitempty"`
+	StationParameter  string            `json:"stationParameter,omitempty"`
+}
+
+// OrderTemplate describes the nodes of an order; edges are generated between consecutive nodes
+type OrderTemplate struct {
+	Nodes []NodeTemplate `json:"nodes"`
+}
+
+// NodeTemplate describes an order node; a random node ID is generated when NodeID is empty
+// The node position is taken from Station when set, and from the command's "@station" when
+// TargetStation is set; Position is used otherwise
+type NodeTemplate struct {
+	NodeID        string           `json:"nodeId,omitempty"`
+	Description   string           `json:"description,omitempty"`
+	Position      NodePosition     `json:"position"`
+	Station       string           `json:"station,omitempty"`
+	TargetStation bool             `json:"targetStation,omitempty"`
+	Actions       []ActionTemplate `json:"actions,omitempty"`
+}
+
+// catalogEntry is a catalog entry with its compiled command pattern
+type catalogEntry struct {
+	ActionCatalogEntry
+	matcher    *regexp.Regexp
+	paramNames []string
+}
+
+// ActionCatalog resolves PLC commands to catalog entries in file order
+type ActionCatalog struct {
+	source  string
+	entries []*catalogEntry
+}
+
+// LoadActionCatalog loads the action catalog from a JSON file, or the built-in catalog if path is empty
+func LoadActionCatalog(path string) (*ActionCatalog, error) {
+	data := defaultActionCatalog
+	source := "built-in"
+	if path != "" {
+		fileData, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read action catalog: %w", err)
+		}
+		data = fileData
+		source = path
+	}
+
+	var file ActionCatalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse action catalog %s: %w", source, err)
+	}
+	if len(file.Actions) == 0 {
+		return nil, fmt.Errorf("action catalog %s defines no actions", source)
+	}
+
+	catalog := &ActionCatalog{source: source}
+	names := make(map[string]bool)
+	for i := range file.Actions {
+		entry, err := compileCatalogEntry(file.Actions[i])
+		if err != nil {
+			return nil, fmt.Errorf("action catalog %s entry %d: %w", source, i, err)
+		}
+		if names[entry.Name] {
+			return nil, fmt.Errorf("action catalog %s: duplicate action name '%s'", source, entry.Name)
+		}
+		names[entry.Name] = true
+		catalog.entries = append(catalog.entries, entry)
+	}
+	return catalog, nil
+}
+
+// compileCatalogEntry validates an entry and compiles its command pattern
+func compileCatalogEntry(entry ActionCatalogEntry) (*catalogEntry, error) {
+	if entry.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if entry.Pattern == "" {
+		return nil, fmt.Errorf("'%s': pattern is required", entry.Name)
+	}
+	definitions := 0
+	for _, defined := range []bool{len(entry.InstantActions) > 0, entry.Order != nil, len(entry.Sequence) > 0} {
+		if defined {
+			definitions++
+		}
+	}
+	if definitions != 1 {
+		return nil, fmt.Errorf("'%s': exactly one of instantActions, order or sequence is required", entry.Name)
+	}
+	if entry.Order != nil && len(entry.Order.Nodes) == 0 {
+		return nil, fmt.Errorf("'%s': order must contain at least one node", entry.Name)
+	}
+	if entry.BusyPolicy != "" && !entry.BusyPolicy.IsValid() {
+		return nil, fmt.Errorf("'%s': busyPolicy must be one of reject, queue, preempt or send", entry.Name)
+	}
+	if strings.Contains(entry.Pattern, "@") {
+		return nil, fmt.Errorf("'%s': pattern must not contain '@', it is reserved for target stations", entry.Name)
+	}
+	if strings.Contains(entry.Pattern, requestIDSeparator) {
+		return nil, fmt.Errorf("'%s': pattern must not contain '%s', it is reserved for request ids", entry.Name, requestIDSeparator)
+	}
+	if strings.HasPrefix(entry.Pattern, sequencePrefix) || strings.HasPrefix(entry.Pattern, orderUpdatePrefix) {
+		return nil, fmt.Errorf("'%s': pattern must not start with '%s' or '%s'", entry.Name, sequencePrefix, orderUpdatePrefix)
+	}
+
+	matcher, paramNames, err := compileCommandPattern(entry.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %w", entry.Name, err)
+	}
+
+	compiled := &catalogEntry{
+		ActionCatalogEntry: entry,
+		matcher:            matcher,
+		paramNames:         paramNames,
+	}
+
+	// Check every template against the captured parameter names
+	probe := make(map[string]string, len(paramNames))
+	for _, name := range paramNames {
+		probe[name] = "0"
+	}
+	if err := compiled.checkTemplates(probe); err != nil {
+		return nil, err
+	}
+	if entry.Order != nil {
+		for _, node := range entry.Order.Nodes {
+			if node.Station != "" && node.TargetStation {
+				return nil, fmt.Errorf("'%s': node cannot set both station and targetStation", entry.Name)
+			}
+		}
+	}
+	return compiled, nil
+}
+
+// checkTemplates expands every template of the entry with the given parameters without building a command
+func (ce *catalogEntry) checkTemplates(params map[string]string) error {
+	for _, template := range ce.InstantActions {
+		if _, err := expandActionTemplate(template, params, "", nil); err != nil {
+			return fmt.Errorf("'%s': %w", ce.Name, err)
+		}
+	}
+	for _, step := range ce.Sequence {
+		if _, err := expandTemplateString(step, params); err != nil {
+			return fmt.Errorf("'%s': %w", ce.Name, err)
+		}
+	}
+	if ce.Order != nil {
+		for _, node := range ce.Order.Nodes {
+			if _, err := expandTemplateString(node.NodeID, params); err != nil {
+				return fmt.Errorf("'%s': %w", ce.Name, err)
+			}
+			if _, err := expandTemplateString(node.Description, params); err != nil {
+				return fmt.Errorf("'%s': %w", ce.Name, err)
+			}
+			for _, template := range node.Actions {
+				if _, err := expandActionTemplate(template, params, "", nil); err != nil {
+					return fmt.Errorf("'%s': %w", ce.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// compileCommandPattern turns "I:{inference_name}" into an anchored regular expression
+// Every placeholder captures a non-empty value
+func compileCommandPattern(pattern string) (*regexp.Regexp, []string, error) {
+	var expr strings.Builder
+	var paramNames []string
+	seen := make(map[string]bool)
+
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range catalogPlaceholderPattern.FindAllStringSubmatchIndex(pattern, -1) {
+		if loc[0] == last && last > 0 {
+			return nil, nil, fmt.Errorf("pattern '%s' has adjacent placeholders", pattern)
+		}
+		name := pattern[loc[2]:loc[3]]
+		if loc[4] >= 0 {
+			return nil, nil, fmt.Errorf("pattern '%s' must not type placeholder {%s}, types belong in templates", pattern, name)
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("pattern '%s' repeats placeholder {%s}", pattern, name)
+		}
+		seen[name] = true
+		paramNames = append(paramNames, name)
+
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		expr.WriteString("(.+?)")
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	matcher, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+	return matcher, paramNames, nil
+}
+
+// Source returns where the catalog was loaded from
+func (ac *ActionCatalog) Source() string {
+	return ac.source
+}
+
+// Len returns the number of catalog entries
+func (ac *ActionCatalog) Len() int {
+	return len(ac.entries)
+}
+
+// CheckStations verifies that every fixed station referenced by the catalog exists in the registry
+func (ac *ActionCatalog) CheckStations(registry *StationRegistry) error {
+	for _, entry := range ac.entries {
+		if entry.Order == nil {
+			continue
+		}
+		for _, node := range entry.Order.Nodes {
+			if node.Station == "" {
+				continue
+			}
+			if _, err := registry.GetStation(node.Station); err != nil {
+				return fmt.Errorf("action %s: %w", entry.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// acceptsTargetStation reports whether the entry has a node or action parameter that takes a "@station"
+func (ce *catalogEntry) acceptsTargetStation() bool {
+	for _, template := range ce.InstantActions {
+		if template.StationParameter != "" {
+			return true
+		}
+	}
+	if ce.Order == nil {
+		return false
+	}
+	for _, node := range ce.Order.Nodes {
+		if node.TargetStation {
+			return true
+		}
+	}
+	return false
+}
+
+// Match finds the first catalog entry matching a PLC command and returns the captured parameters
+func (ac *ActionCatalog) Match(command string) (*catalogEntry, map[string]string, error) {
+	for _, entry := range ac.entries {
+		groups := entry.matcher.FindStringSubmatch(command)
+		if groups == nil {
+			continue
+		}
+
+		params := make(map[string]string, len(entry.paramNames))
+		for i, name := range entry.paramNames {
+			value := strings.TrimSpace(groups[i+1])
+			if value == "" {
+				return nil, nil, fmt.Errorf("parameter '%s' is required for action %s", name, entry.Name)
+			}
+			params[name] = value
+		}
+		return entry, params, nil
+	}
+	return nil, nil, fmt.Errorf("unknown action type: %s", command)
+}
+
+// BuildCommand builds the command of the named entry from its parameters, the inverse of Match
+// Used for JSON PLC messages that name the action and pass parameters as a map
+func (ac *ActionCatalog) BuildCommand(name string, params map[string]string) (string, error) {
+	var entry *catalogEntry
+	for _, candidate := range ac.entries {
+		if candidate.Name == name {
+			entry = candidate
+			break
+		}
+	}
+	if entry == nil {
+		return "", fmt.Errorf("unknown action: %s", name)
+	}
+
+	trimmed := make(map[string]string, len(params))
+	for key, value := range params {
+		if !slices.Contains(entry.paramNames, key) {
+			return "", fmt.Errorf("action %s has no parameter '%s'", name, key)
+		}
+		if strings.Contains(value, "@") {
+			return "", fmt.Errorf("parameter '%s' must not contain '@', it is reserved for target stations", key)
+		}
+		trimmed[key] = strings.TrimSpace(value)
+	}
+	for _, key := range entry.paramNames {
+		if trimmed[key] == "" {
+			return "", fmt.Errorf("parameter '%s' is required for action %s", key, name)
+		}
+	}
+
+	command, err := expandTemplateString(entry.Pattern, trimmed)
+	if err != nil {
+		return "", err
+	}
+
+	// The command must read back as the same entry and parameters, which fails if a value contains a delimiter of the pattern
+	matched, matchedParams, err := ac.Match(command)
+	if err != nil || matched != entry || !maps.Equal(matchedParams, trimmed) {
+		return "", fmt.Errorf("parameters of action %s cannot be encoded as '%s'", name, entry.Pattern)
+	}
+	return command, nil
+}
+
+// expandActionTemplate builds an action from a template with the given action ID
+// A target station, if given, is set as pose on the template's station parameter
+func expandActionTemplate(template ActionTemplate, params map[string]string, actionID string, targetStation *NodePosition) (Action, error) {
+	description, err := expandTemplateString(template.ActionDescription, params)
+	if err != nil {
+		return Action{}, err
+	}
+
+	parameters := make([]ActionParameter, 0, len(template.Parameters))
+	for _, parameter := range template.Parameters {
+		value, err := expandTemplateValue(parameter.Value, params)
+		if err != nil {
+			return Action{}, fmt.Errorf("parameter '%s': %w", parameter.Key, err)
+		}
+		parameters = append(parameters, ActionParameter{Key: parameter.Key, Value: value})
+	}
+
+	if template.StationParameter != "" && targetStation != nil {
+		pose := Pose{
+			MapID: targetStation.MapID,
+			Theta: targetStation.Theta,
+			X:     targetStation.X,
+			Y:     targetStation.Y,
+		}
+		replaced := false
+		for i := range parameters {
+			if parameters[i].Key == template.StationParameter {
+				parameters[i].Value = pose
+				replaced = true
+			}
+		}
+		if !replaced {
+			parameters = append(parameters, ActionParameter{Key: template.StationParameter, Value: pose})
+		}
+	}
+
+	blockingType := template.BlockingType
+	if blockingType == "" {
+		blockingType = "NONE"
+	}
+
+	return Action{
+		ActionType:        template.ActionType,
+		ActionID:          actionID,
+		ActionDescription: description,
+		BlockingType:      blockingType,
+		ActionParameters:  parameters,
+	}, nil
+}
+
+// expandTemplateValue substitutes placeholders in strings nested in a parameter value
+func expandTemplateValue(value interface{}, params map[string]string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if groups := catalogPlaceholderPattern.FindStringSubmatch(v); groups != nil && groups[0] == v && groups[2] != "" {
+			return convertTypedPlaceholder(groups[1], groups[2], params)
+		}
+		return expandTemplateString(v, params)
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			expandedItem, err := expandTemplateValue(item, params)
+			if err != nil {
+				return nil, err
+			}
+			expanded[key] = expandedItem
+		}
+		return expanded, nil
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, item := range v {
+			expandedItem, err := expandTemplateValue(item, params)
+			if err != nil {
+				return nil, err
+			}
+			expanded[i] = expandedItem
+		}
+		return expanded, nil
+	default:
+		return value, nil
+	}
+}
+
+// convertTypedPlaceholder converts a captured parameter to the placeholder's type
+func convertTypedPlaceholder(name string, valueType string, params map[string]string) (interface{}, error) {
+	value, exists := params[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown placeholder {%s}", name)
+	}
+
+	switch valueType {
+	case "number":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s' must be a number, got '%s'", name, value)
+		}
+		return number, nil
+	case "integer":
+		integer, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s' must be an integer, got '%s'", name, value)
+		}
+		return integer, nil
+	case "bool":
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s' must be a bool, got '%s'", name, value)
+		}
+		return boolean, nil
+	default:
+		return nil, fmt.Errorf("placeholder {%s|%s} has unknown type", name, valueType)
+	}
+}
+
+// expandTemplateString substitutes {name} placeholders with captured parameters
+// Typed placeholders embedded in a longer string are substituted as text
+func expandTemplateString(s string, params map[string]string) (string, error) {
+	var missing string
+	expanded := catalogPlaceholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := catalogPlaceholderPattern.FindStringSubmatch(placeholder)[1]
+		value, exists := params[name]
+		if !exists {
+			missing = name
+			return placeholder
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("unknown placeholder {%s} in '%s'", missing, s)
+	}
+	return expanded, nil
+}
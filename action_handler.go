@@ -1,21 +1,40 @@
 package main
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// sequencePrefix starts a PLC action that combines several order steps into one order
+const sequencePrefix = "SEQ:"
+
+// orderUpdatePrefix starts a PLC action that appends steps to the robot's running order
+const orderUpdatePrefix = "EXT:"
+
+// requestIDSeparator separates an optional PLC request id from the action ("DEX0002:I:inference1#req-42")
+const requestIDSeparator = "#"
+
+// maxSequenceSteps limits how many steps a single sequence order may contain
+const maxSequenceSteps = 20
+
 // ActionHandler handles action conversion from PLC to Robot format
 type ActionHandler struct {
-	headerIDCounter int
+	headerIDCounter int32 // shared by the PLC handlers, the dispatcher and the status monitor
+	catalog         *ActionCatalog
+	stations        *StationRegistry
 }
 
-// NewActionHandler creates a new action handler
-func NewActionHandler() *ActionHandler {
+// NewActionHandler creates a new action handler using the given action catalog and station registry
+func NewActionHandler(catalog *ActionCatalog, stations *StationRegistry) *ActionHandler {
 	return &ActionHandler{
 		headerIDCounter: 1,
+		catalog:         catalog,
+		stations:        stations,
 	}
 }
 
@@ -35,13 +54,12 @@ func (ah *ActionHandler) generateOrderID() string {
 
 // getNextHeaderID returns the next header ID
 func (ah *ActionHandler) getNextHeaderID() int {
-	ah.headerIDCounter++
-	return ah.headerIDCounter
+	return int(atomic.AddInt32(&ah.headerIDCounter, 1))
 }
 
-// createBaseRobotMessage creates a base robot message with common fields
-func (ah *ActionHandler) createBaseRobotMessage(serialNumber string, manufacturer string) *RobotActionMessage {
-	return &RobotActionMessage{
+// createMessageHeader creates a message header with common fields
+func (ah *ActionHandler) createMessageHeader(serialNumber string, manufacturer string) MessageHeader {
+	return MessageHeader{
 		HeaderID:     ah.getNextHeaderID(),
 		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
 		Version:      "2.0.0",
@@ -50,253 +68,419 @@ func (ah *ActionHandler) createBaseRobotMessage(serialNumber string, manufacture
 	}
 }
 
-// createBaseNodePosition creates a base node position with default values
-func (ah *ActionHandler) createBaseNodePosition() NodePosition {
-	return NodePosition{
-		X:                     0.0,
-		Y:                     0.0,
-		Theta:                 0.0,
-		AllowedDeviationXY:    0.5,
-		AllowedDeviationTheta: 0.17453292, // 10 degrees in radians
-		MapID:                 "floor 0",
+// createInstantActionsCommand wraps actions into an instant actions command
+func (ah *ActionHandler) createInstantActionsCommand(serialNumber string, manufacturer string, actions ...Action) *RobotCommand {
+	return &RobotCommand{
+		Kind: CommandInstantActions,
+		InstantActions: &InstantActionsMessage{
+			MessageHeader: ah.createMessageHeader(serialNumber, manufacturer),
+			Actions:       actions,
+		},
 	}
 }
 
-// createInferenceNodePosition creates a specific position for inference actions
-func (ah *ActionHandler) createInferenceNodePosition() NodePosition {
-	return NodePosition{
-		X:                     -4.16,
-		Y:                     -0.39,
-		Theta:                 3.1415927, // 180 degrees in radians
-		AllowedDeviationXY:    0.5,
-		AllowedDeviationTheta: 0.17453292, // 10 degrees in radians
-		MapID:                 "floor 0",
+// createOrderCommand wraps nodes and edges into a new order command
+func (ah *ActionHandler) createOrderCommand(serialNumber string, manufacturer string, nodes []Node, edges []Edge) *RobotCommand {
+	return &RobotCommand{
+		Kind: CommandOrder,
+		Order: &OrderMessage{
+			MessageHeader: ah.createMessageHeader(serialNumber, manufacturer),
+			OrderID:       ah.generateOrderID(),
+			OrderUpdateID: 0,
+			Nodes:         nodes,
+			Edges:         edges,
+		},
 	}
 }
 
-// ConvertPLCActionToRobotAction converts PLC action message to robot action message
-func (ah *ActionHandler) ConvertPLCActionToRobotAction(plcAction *PLCActionMessage, serialNumber string) (*RobotActionMessage, error) {
-	switch plcAction.Action {
-	case "init":
-		return ah.createInitPositionAction(serialNumber), nil
-	case "factsheetRequest":
-		return ah.createFactsheetRequestAction(serialNumber, "Roboligent"), nil
-	case "cancelOrder":
-		return ah.createCancelOrderAction(serialNumber), nil
-	default:
-		// Check if it's an inference action (format: I:inference_name)
-		if strings.HasPrefix(plcAction.Action, "I:") {
-			inferenceName := strings.TrimPrefix(plcAction.Action, "I:")
-			if inferenceName == "" {
-				return nil, fmt.Errorf("inference name is required for inference action")
-			}
-			return ah.createInferenceAction(serialNumber, inferenceName), nil
+// resolvedStep is a catalog entry matched for one PLC command (or one step of a sequence)
+type resolvedStep struct {
+	entry         *catalogEntry
+	params        map[string]string
+	targetStation *NodePosition
+}
+
+// resolvePLCAction resolves a PLC action into the catalog steps it consists of
+// A sequence ("SEQ:T:pick,I:inspect@stationB,T:place") or a recipe entry resolves to several order steps
+func (ah *ActionHandler) resolvePLCAction(action string) ([]resolvedStep, error) {
+	if strings.HasPrefix(action, sequencePrefix) {
+		return ah.resolveSequence(strings.Split(strings.TrimPrefix(action, sequencePrefix), ","))
+	}
+
+	step, err := ah.resolveStep(action)
+	if err != nil {
+		return nil, err
+	}
+	if step.entry.Sequence == nil {
+		return []resolvedStep{step}, nil
+	}
+	if step.targetStation != nil {
+		return nil, fmt.Errorf("recipe %s does not accept a target station", step.entry.Name)
+	}
+
+	// Expand recipe steps with the parameters captured by the recipe pattern
+	commands := make([]string, 0, len(step.entry.Sequence))
+	for _, template := range step.entry.Sequence {
+		command, err := expandTemplateString(template, step.params)
+		if err != nil {
+			return nil, fmt.Errorf("recipe %s: %w", step.entry.Name, err)
 		}
-		// Check if it's a trajectory action (format: T:trajectory_name)
-		if strings.HasPrefix(plcAction.Action, "T:") {
-			trajectoryName := strings.TrimPrefix(plcAction.Action, "T:")
-			if trajectoryName == "" {
-				return nil, fmt.Errorf("trajectory name is required for trajectory action")
-			}
-			return ah.createTrajectoryAction(serialNumber, trajectoryName), nil
+		commands = append(commands, command)
+	}
+	steps, err := ah.resolveSequence(commands)
+	if err != nil {
+		return nil, fmt.Errorf("recipe %s: %w", step.entry.Name, err)
+	}
+	return steps, nil
+}
+
+// resolveSequence resolves every step of a sequence; each step must be an order entry
+func (ah *ActionHandler) resolveSequence(commands []string) ([]resolvedStep, error) {
+	if len(commands) > maxSequenceSteps {
+		return nil, fmt.Errorf("sequence has %d steps, maximum is %d", len(commands), maxSequenceSteps)
+	}
+
+	steps := make([]resolvedStep, 0, len(commands))
+	for i, command := range commands {
+		command = strings.TrimSpace(command)
+		if command == "" {
+			return nil, fmt.Errorf("sequence step %d is empty", i+1)
+		}
+
+		step, err := ah.resolveStep(command)
+		if err != nil {
+			return nil, fmt.Errorf("sequence step %d: %w", i+1, err)
+		}
+		if step.entry.Order == nil {
+			return nil, fmt.Errorf("sequence step %d: action %s is not an order", i+1, step.entry.Name)
 		}
-		return nil, fmt.Errorf("unsupported action type: %s", plcAction.Action)
+		steps = append(steps, step)
 	}
+	return steps, nil
 }
 
-// createInitPositionAction creates an init position action for the robot
-func (ah *ActionHandler) createInitPositionAction(serialNumber string) *RobotActionMessage {
-	pose := Pose{
-		LastNodeID: "",
-		MapID:      "",
-		Theta:      0.0,
-		X:          0.0,
-		Y:          0.0,
+// resolveStep matches a single command (with optional "@station" suffix) against the catalog
+// and resolves the target station pose
+func (ah *ActionHandler) resolveStep(action string) (resolvedStep, error) {
+	command, stationName := splitStationSuffix(action)
+
+	entry, params, err := ah.catalog.Match(command)
+	if err != nil {
+		return resolvedStep{}, err
 	}
 
-	action := Action{
-		ActionType:       "initPosition",
-		ActionID:         ah.generateActionID(),
-		BlockingType:     "NONE",
-		ActionParameters: []ActionParameter{{Key: "pose", Value: pose}},
+	step := resolvedStep{entry: entry, params: params}
+	if !strings.Contains(action, "@") {
+		return step, nil
+	}
+	if stationName == "" {
+		return resolvedStep{}, fmt.Errorf("station name is required after '@'")
+	}
+	if !entry.acceptsTargetStation() {
+		return resolvedStep{}, fmt.Errorf("action %s does not accept a target station", entry.Name)
 	}
 
-	robotAction := ah.createBaseRobotMessage(serialNumber, "Roboligent")
-	robotAction.Actions = []Action{action}
-	return robotAction
+	station, err := ah.stations.GetStation(stationName)
+	if err != nil {
+		return resolvedStep{}, err
+	}
+	step.targetStation = &station
+	return step, nil
 }
 
-// createFactsheetRequestAction creates a factsheet request action for the robot
-func (ah *ActionHandler) createFactsheetRequestAction(serialNumber string, manufacturer string) *RobotActionMessage {
-	action := Action{
-		ActionType:       "factsheetRequest",
-		ActionID:         ah.generateActionID(),
-		BlockingType:     "NONE",
-		ActionParameters: []ActionParameter{}, // Empty parameters
+// ConvertPLCActionToRobotCommand converts PLC action message to a robot order or instant actions command
+// using the matching action catalog entries
+// Format: "I:inference1", "I:inference1@stationB" to send the order to a registered station,
+// "SEQ:T:pick,I:inspect,T:place" to combine several steps into one order, or a recipe name from the catalog
+func (ah *ActionHandler) ConvertPLCActionToRobotCommand(plcAction *PLCActionMessage, serialNumber string, manufacturer string) (*RobotCommand, error) {
+	steps, err := ah.resolvePLCAction(plcAction.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(steps) > 1 || steps[0].entry.Order != nil {
+		return ah.createCatalogOrder(steps, serialNumber, manufacturer)
 	}
 
-	robotAction := ah.createBaseRobotMessage(serialNumber, manufacturer)
-	robotAction.Actions = []Action{action}
-	return robotAction
+	entry, params := steps[0].entry, steps[0].params
+	actions := make([]Action, 0, len(entry.InstantActions))
+	for _, template := range entry.InstantActions {
+		action, err := expandActionTemplate(template, params, ah.generateActionID(), steps[0].targetStation)
+		if err != nil {
+			return nil, fmt.Errorf("action %s: %w", entry.Name, err)
+		}
+		actions = append(actions, action)
+	}
+	return ah.createInstantActionsCommand(serialNumber, manufacturer, actions...), nil
 }
 
-// createInferenceAction creates an inference action for the robot
-func (ah *ActionHandler) createInferenceAction(serialNumber string, inferenceName string) *RobotActionMessage {
-	// Create intermediate node (starting point)
-	intermediateNode := Node{
-		NodeID:       "intermediate_node_0_0",
-		Description:  fmt.Sprintf("intermediate point 0 of task inference-%s subtask index 0", inferenceName),
-		SequenceID:   0,
-		Released:     true,
-		NodePosition: ah.createBaseNodePosition(),
-		Actions:      []Action{}, // Empty actions for intermediate node
-	}
-
-	// Create inference action
-	inferenceAction := Action{ // Changed from NodeAction to Action
-		ActionType:        "Roboligent Robin - Inference",
-		ActionID:          ah.generateActionID(),
-		ActionDescription: "This is an action will trigger the behavior tree for executing inference.",
-		BlockingType:      "NONE",
-		ActionParameters: []ActionParameter{
-			{Key: "inference_name", Value: inferenceName},
-		},
+// createCatalogOrder builds one order from the order templates of all steps
+func (ah *ActionHandler) createCatalogOrder(steps []resolvedStep, serialNumber string, manufacturer string) (*RobotCommand, error) {
+	nodes, err := ah.buildCatalogNodes(steps, 0, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	return ah.createOrderCommand(serialNumber, manufacturer, nodes, linkOrderNodes(nodes)), nil
+}
+
+// ConvertPLCActionToOrderUpdate converts an "EXT:" PLC action into an update of a running order
+// The update repeats the last base node (stitching node) and continues its sequence IDs
+// Format: "EXT:I:inspect2", "EXT:T:place@stationB" or "EXT:SEQ:..."
+func (ah *ActionHandler) ConvertPLCActionToOrderUpdate(plcAction *PLCActionMessage, base *TrackedOrder) (*RobotCommand, error) {
+	steps, err := ah.resolveOrderUpdate(plcAction.Action)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create inference node
-	inferenceNode := Node{
-		NodeID:       ah.generateActionID(),
-		Description:  fmt.Sprintf("we are in 2 Subtask of inference-%s at index 0", inferenceName),
-		SequenceID:   2,
-		Released:     true,
-		NodePosition: ah.createInferenceNodePosition(),
-		Actions:      []Action{inferenceAction}, // Changed from []NodeAction to []Action
+	usedNodeIDs := make(map[string]bool, len(base.Nodes))
+	for _, node := range base.Nodes {
+		usedNodeIDs[node.NodeID] = true
 	}
 
-	// Create edge connecting the nodes
-	edge := Edge{
-		EdgeID:      "intermediate_edge_0_0",
-		SequenceID:  1,
-		Released:    true,
-		StartNodeID: "intermediate_node_0_0",
-		EndNodeID:   inferenceNode.NodeID,
-		Actions:     []Action{}, // Empty actions for edge
+	stitchNode := base.stitchNode
+	stitchNode.Actions = []Action{} // Actions of the stitching node were sent with the base
+	newNodes, err := ah.buildCatalogNodes(steps, stitchNode.SequenceID+2, usedNodeIDs)
+	if err != nil {
+		return nil, err
 	}
+	nodes := append([]Node{stitchNode}, newNodes...)
+
+	return &RobotCommand{
+		Kind: CommandOrder,
+		Order: &OrderMessage{
+			MessageHeader: ah.createMessageHeader(base.SerialNumber, base.Manufacturer),
+			OrderID:       base.OrderID,
+			OrderUpdateID: base.OrderUpdateID + 1,
+			Nodes:         nodes,
+			Edges:         linkOrderNodes(nodes),
+		},
+	}, nil
+}
 
-	// Create robot action message (order format)
-	robotAction := ah.createBaseRobotMessage(serialNumber, "Roboligent")
-	robotAction.OrderID = ah.generateOrderID()
-	robotAction.OrderUpdateID = 0
-	robotAction.Nodes = []Node{intermediateNode, inferenceNode}
-	robotAction.Edges = []Edge{edge}
+// resolveOrderUpdate resolves the steps appended by an order update; every step must be an order
+func (ah *ActionHandler) resolveOrderUpdate(action string) ([]resolvedStep, error) {
+	steps, err := ah.resolvePLCAction(strings.TrimPrefix(action, orderUpdatePrefix))
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		if step.entry.Order == nil {
+			return nil, fmt.Errorf("action %s is not an order and cannot extend an order", step.entry.Name)
+		}
+	}
+	return steps, nil
+}
 
-	return robotAction
+// IsOrderUpdateAction reports whether a PLC action extends the robot's running order
+func IsOrderUpdateAction(action string) bool {
+	return strings.HasPrefix(action, orderUpdatePrefix)
 }
 
-// createTrajectoryAction creates a trajectory action for the robot
-func (ah *ActionHandler) createTrajectoryAction(serialNumber string, trajectoryName string) *RobotActionMessage {
-	// Create intermediate node (starting point)
-	intermediateNode := Node{
-		NodeID:       "intermediate_node_0_0",
-		Description:  fmt.Sprintf("intermediate point 0 of task trajectory-%s subtask index 0", trajectoryName),
-		SequenceID:   0,
-		Released:     true,
-		NodePosition: ah.createBaseNodePosition(),
-		Actions:      []Action{}, // Empty actions for intermediate node
-	}
-
-	// Create trajectory action
-	trajectoryAction := Action{ // Changed from NodeAction to Action
-		ActionType:        "Roboligent Robin - Follow Trajectory",
-		ActionID:          ah.generateActionID(),
-		ActionDescription: "This action will trigger the behavior tree for following a recorded trajectory.",
-		BlockingType:      "NONE",
-		ActionParameters: []ActionParameter{
-			{Key: "arm", Value: "right"}, // Default arm setting
-			{Key: "trajectory_name", Value: trajectoryName},
-		},
+// buildCatalogNodes builds the nodes of all steps with even sequence IDs starting at firstSequenceID
+// Fixed node IDs that are already used get the sequence ID appended to keep them unique
+func (ah *ActionHandler) buildCatalogNodes(steps []resolvedStep, firstSequenceID int, usedNodeIDs map[string]bool) ([]Node, error) {
+	var nodes []Node
+	for _, step := range steps {
+		entry, params := step.entry, step.params
+
+		for _, template := range entry.Order.Nodes {
+			sequenceID := firstSequenceID + len(nodes)*2
+
+			nodeID, err := expandTemplateString(template.NodeID, params)
+			if err != nil {
+				return nil, fmt.Errorf("action %s: %w", entry.Name, err)
+			}
+			if nodeID == "" {
+				nodeID = ah.generateActionID()
+			} else if usedNodeIDs[nodeID] {
+				nodeID = fmt.Sprintf("%s_%d", nodeID, sequenceID)
+			}
+			usedNodeIDs[nodeID] = true
+
+			description, err := expandTemplateString(template.Description, params)
+			if err != nil {
+				return nil, fmt.Errorf("action %s: %w", entry.Name, err)
+			}
+
+			actions := make([]Action, 0, len(template.Actions))
+			for _, actionTemplate := range template.Actions {
+				action, err := expandActionTemplate(actionTemplate, params, ah.generateActionID(), nil)
+				if err != nil {
+					return nil, fmt.Errorf("action %s: %w", entry.Name, err)
+				}
+				actions = append(actions, action)
+			}
+
+			position := template.Position
+			if template.Station != "" {
+				if position, err = ah.stations.GetStation(template.Station); err != nil {
+					return nil, fmt.Errorf("action %s: %w", entry.Name, err)
+				}
+			} else if template.TargetStation && step.targetStation != nil {
+				position = *step.targetStation
+			}
+
+			nodes = append(nodes, Node{
+				NodeID:       nodeID,
+				Description:  description,
+				SequenceID:   sequenceID,
+				Released:     true,
+				NodePosition: position,
+				Actions:      actions,
+			})
+		}
 	}
+	return nodes, nil
+}
 
-	// Create trajectory execution node
-	trajectoryNode := Node{
-		NodeID:       ah.generateActionID(),
-		Description:  fmt.Sprintf("we are in 2 Subtask of trajectory-%s at index 0", trajectoryName),
-		SequenceID:   2,
-		Released:     true,
-		NodePosition: ah.createInferenceNodePosition(), // Same position as inference
-		Actions:      []Action{trajectoryAction},       // Changed from []NodeAction to []Action
+// linkOrderNodes creates an edge between each pair of consecutive nodes
+// Edges take the odd sequence ID between their nodes
+func linkOrderNodes(nodes []Node) []Edge {
+	edges := make([]Edge, 0, len(nodes))
+	for i := 1; i < len(nodes); i++ {
+		sequenceID := nodes[i-1].SequenceID + 1
+		edges = append(edges, Edge{
+			EdgeID:      fmt.Sprintf("intermediate_edge_%d_0", sequenceID/2),
+			SequenceID:  sequenceID,
+			Released:    true,
+			StartNodeID: nodes[i-1].NodeID,
+			EndNodeID:   nodes[i].NodeID,
+			Actions:     []Action{}, // Empty actions for edge
+		})
 	}
+	return edges
+}
 
-	// Create edge connecting the nodes
-	edge := Edge{
-		EdgeID:      "intermediate_edge_0_0",
-		SequenceID:  1,
-		Released:    true,
-		StartNodeID: "intermediate_node_0_0",
-		EndNodeID:   trajectoryNode.NodeID,
-		Actions:     []Action{}, // Empty actions for edge
+// createFactsheetRequestAction creates a factsheet request action for the robot
+// Used by the bridge itself, independent of the action catalog
+func (ah *ActionHandler) createFactsheetRequestAction(serialNumber string, manufacturer string) *RobotCommand {
+	action := Action{
+		ActionType:       "factsheetRequest",
+		ActionID:         ah.generateActionID(),
+		BlockingType:     "NONE",
+		ActionParameters: []ActionParameter{}, // Empty parameters
 	}
 
-	// Create robot action message (order format)
-	robotAction := ah.createBaseRobotMessage(serialNumber, "Roboligent")
-	robotAction.OrderID = ah.generateOrderID()
-	robotAction.OrderUpdateID = 0
-	robotAction.Nodes = []Node{intermediateNode, trajectoryNode}
-	robotAction.Edges = []Edge{edge}
+	return ah.createInstantActionsCommand(serialNumber, manufacturer, action)
+}
+
+// createInitPositionAction creates an initPosition instant action setting the robot to the given pose
+// Used by the bridge itself to restore a stored position, independent of the action catalog
+func (ah *ActionHandler) createInitPositionAction(serialNumber string, manufacturer string, pose Pose) *RobotCommand {
+	action := Action{
+		ActionType:       "initPosition",
+		ActionID:         ah.generateActionID(),
+		BlockingType:     "NONE",
+		ActionParameters: []ActionParameter{{Key: "pose", Value: pose}},
+	}
 
-	return robotAction
+	return ah.createInstantActionsCommand(serialNumber, manufacturer, action)
 }
 
-// createCancelOrderAction creates a cancel order action for the robot
-func (ah *ActionHandler) createCancelOrderAction(serialNumber string) *RobotActionMessage {
-	// Create cancel order action (no parameters needed)
+// createCancelOrderAction creates a cancelOrder instant action for the robot
+// Used by the bridge itself to preempt a running order, independent of the action catalog
+func (ah *ActionHandler) createCancelOrderAction(serialNumber string, manufacturer string) *RobotCommand {
 	action := Action{
 		ActionType:       "cancelOrder",
 		ActionID:         ah.generateActionID(),
 		BlockingType:     "HARD",
-		ActionParameters: []ActionParameter{}, // Empty parameters
+		ActionParameters: []ActionParameter{},
 	}
 
-	// Create robot action message (simple format)
-	robotAction := ah.createBaseRobotMessage(serialNumber, "Roboligent")
-	robotAction.Actions = []Action{action}
-	return robotAction
+	return ah.createInstantActionsCommand(serialNumber, manufacturer, action)
 }
 
-// ValidatePLCAction validates the PLC action message
-func ValidatePLCAction(plcAction *PLCActionMessage) error {
-	if plcAction.Action == "" {
-		return fmt.Errorf("action is required")
+// BusyPolicy returns what to do with a PLC action while its robot executes an order
+// Order updates always go through since they extend the running order
+func (ah *ActionHandler) BusyPolicy(action string, orderDefault BusyPolicy) (BusyPolicy, error) {
+	if IsOrderUpdateAction(action) {
+		return BusySend, nil
+	}
+	if strings.HasPrefix(action, sequencePrefix) {
+		return orderDefault, nil
 	}
 
-	// Validate known actions
-	switch plcAction.Action {
-	case "init", "factsheetRequest", "cancelOrder":
-		return nil
+	step, err := ah.resolveStep(action)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case step.entry.BusyPolicy != "":
+		return step.entry.BusyPolicy, nil
+	case len(step.entry.InstantActions) > 0:
+		return BusySend, nil
 	default:
-		// Check parametric actions
-		if strings.HasPrefix(plcAction.Action, "I:") {
-			inferenceName := strings.TrimPrefix(plcAction.Action, "I:")
-			if inferenceName == "" {
-				return fmt.Errorf("inference name is required for inference action")
-			}
-			return nil
+		return orderDefault, nil
+	}
+}
+
+// NormalizePLCAction folds the parameters and station of a JSON PLC message into the command grammar,
+// e.g. {"action": "inference", "parameters": {"inference_name": "inf1"}, "station": "B"} becomes "I:inf1@B"
+func (ah *ActionHandler) NormalizePLCAction(plcAction *PLCActionMessage) error {
+	if len(plcAction.Parameters) > 0 {
+		command, err := ah.catalog.BuildCommand(plcAction.Action, plcAction.Parameters)
+		if err != nil {
+			return err
 		}
-		if strings.HasPrefix(plcAction.Action, "T:") {
-			trajectoryName := strings.TrimPrefix(plcAction.Action, "T:")
-			if trajectoryName == "" {
-				return fmt.Errorf("trajectory name is required for trajectory action")
-			}
-			return nil
+		plcAction.Action = command
+		plcAction.Parameters = nil
+	}
+
+	if plcAction.Station != "" {
+		if strings.Contains(plcAction.Action, "@") {
+			return fmt.Errorf("station %s given for action '%s' that already names a station", plcAction.Station, plcAction.Action)
+		}
+		plcAction.Action += "@" + plcAction.Station
+		plcAction.Station = ""
+	}
+	return nil
+}
+
+// ValidatePLCAction validates the PLC action message against the action catalog and station registry
+func (ah *ActionHandler) ValidatePLCAction(plcAction *PLCActionMessage) error {
+	if plcAction.Action == "" {
+		return fmt.Errorf("action is required")
+	}
+
+	var steps []resolvedStep
+	var err error
+	if IsOrderUpdateAction(plcAction.Action) {
+		steps, err = ah.resolveOrderUpdate(plcAction.Action)
+	} else {
+		steps, err = ah.resolvePLCAction(plcAction.Action)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Check captured parameters against typed placeholders
+	for _, step := range steps {
+		if err := step.entry.checkTemplates(step.params); err != nil {
+			return err
 		}
-		return fmt.Errorf("unknown action type: %s", plcAction.Action)
 	}
+	return nil
+}
+
+// plcActionJSON is the JSON form of a PLC action message
+type plcActionJSON struct {
+	Serial     string                 `json:"serial"`
+	Action     string                 `json:"action"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	RequestID  string                 `json:"requestId,omitempty"`
+	Priority   int                    `json:"priority,omitempty"`
+	Station    string                 `json:"station,omitempty"`
 }
 
 // ParsePLCActionMessage parses PLC action message
-// Only supports format: {serial}:action (e.g., "DEX0002:init", "DEX0002:I:inference1", "DEX0002:T:traj1")
+// Text format: {serial}:action (e.g., "DEX0002:init", "DEX0002:I:inference1", "DEX0002:T:traj1")
+// An optional "#requestId" suffix identifies repeated deliveries of the same command
+// A payload starting with '{' is parsed as JSON, see parsePLCActionJSON
 func ParsePLCActionMessage(payload []byte) (*PLCActionMessage, error) {
 	payloadStr := strings.TrimSpace(string(payload))
+	if strings.HasPrefix(payloadStr, "{") {
+		return parsePLCActionJSON([]byte(payloadStr))
+	}
 
 	// All messages must contain serial:action format
 	if !strings.Contains(payloadStr, ":") {
@@ -309,14 +493,65 @@ func ParsePLCActionMessage(payload []byte) (*PLCActionMessage, error) {
 	}
 
 	serial := strings.TrimSpace(parts[0])
-	action := strings.TrimSpace(parts[1])
+	action, requestID, hasRequestID := strings.Cut(parts[1], requestIDSeparator)
+	action = strings.TrimSpace(action)
+	requestID = strings.TrimSpace(requestID)
 
 	if serial == "" || action == "" {
 		return nil, fmt.Errorf("empty serial or action in '%s'", payloadStr)
 	}
+	if hasRequestID && requestID == "" {
+		return nil, fmt.Errorf("empty request id after '%s' in '%s'", requestIDSeparator, payloadStr)
+	}
+
+	return &PLCActionMessage{
+		Action:       action,
+		SerialNumber: serial,
+		RequestID:    requestID,
+	}, nil
+}
+
+// parsePLCActionJSON parses the JSON form of a PLC action message, e.g.
+// {"serial": "DEX0002", "action": "inference", "parameters": {"inference_name": "inf1"}, "station": "B", "requestId": "req-42", "priority": 1}
+// With parameters, action names a catalog entry; without, it is a command in the text grammar
+func parsePLCActionJSON(payload []byte) (*PLCActionMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var message plcActionJSON
+	if err := decoder.Decode(&message); err != nil {
+		return nil, fmt.Errorf("invalid JSON action message: %w", err)
+	}
+
+	serial := strings.TrimSpace(message.Serial)
+	action := strings.TrimSpace(message.Action)
+	if serial == "" || action == "" {
+		return nil, fmt.Errorf("serial and action are required in JSON action message")
+	}
+
+	var params map[string]string
+	if len(message.Parameters) > 0 {
+		params = make(map[string]string, len(message.Parameters))
+		for key, value := range message.Parameters {
+			switch v := value.(type) {
+			case string:
+				params[key] = v
+			case json.Number:
+				params[key] = v.String()
+			case bool:
+				params[key] = fmt.Sprintf("%t", v)
+			default:
+				return nil, fmt.Errorf("parameter '%s' must be a string, number or bool", key)
+			}
+		}
+	}
 
 	return &PLCActionMessage{
 		Action:       action,
 		SerialNumber: serial,
+		RequestID:    strings.TrimSpace(message.RequestID),
+		Priority:     message.Priority,
+		Parameters:   params,
+		Station:      strings.TrimSpace(message.Station),
 	}, nil
 }
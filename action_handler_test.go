@@ -0,0 +1,188 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestActionHandler creates an action handler with the built-in catalog and a single station "stationB"
+func newTestActionHandler(t *testing.T) *ActionHandler {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stations.json")
+	data := `{"stations": {"stationB": {"x": 1.5, "y": -2, "theta": 0, "allowedDeviationXY": 0.5, "mapId": "floor 0"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, stations, err := LoadCommandDefinitions(&AppConfig{StationRegistryFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewActionHandler(catalog, stations)
+}
+
+func TestParsePLCActionMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    *PLCActionMessage
+		wantErr bool
+	}{
+		{"simple action", "DEX0002:init",
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "init"}, false},
+		{"action with parameter", "DEX0002:I:inference1",
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "I:inference1"}, false},
+		{"surrounding whitespace", "  DEX0002 : init \n",
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "init"}, false},
+		{"qualified serial", "Roboligent/DEX0002:T:traj1",
+			&PLCActionMessage{SerialNumber: "Roboligent/DEX0002", Action: "T:traj1"}, false},
+		{"request id", "DEX0002:I:inference1#req-42",
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "I:inference1", RequestID: "req-42"}, false},
+		{"target station", "DEX0002:I:inference1@stationB",
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "I:inference1@stationB"}, false},
+		{"target station and request id", "DEX0002:I:inference1@stationB#req-7",
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "I:inference1@stationB", RequestID: "req-7"}, false},
+		{"sequence", "DEX0002:SEQ:T:pick,I:inspect@stationB,T:place",
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "SEQ:T:pick,I:inspect@stationB,T:place"}, false},
+		{"order update", "DEX0002:EXT:I:inference2#req-8",
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "EXT:I:inference2", RequestID: "req-8"}, false},
+		{"group target", "@cellA:stopPause",
+			&PLCActionMessage{SerialNumber: "@cellA", Action: "stopPause"}, false},
+		{"fleet target", "*:startPause",
+			&PLCActionMessage{SerialNumber: "*", Action: "startPause"}, false},
+		{"JSON text grammar", `{"serial": "DEX0002", "action": "I:inference1", "requestId": "req-1"}`,
+			&PLCActionMessage{SerialNumber: "DEX0002", Action: "I:inference1", RequestID: "req-1"}, false},
+		{"JSON with parameters", `{"serial": "DEX0002", "action": "inference", "parameters": {"inference_name": "inf1", "count": 3, "dry": true}, "station": "stationB", "priority": 2}`,
+			&PLCActionMessage{
+				SerialNumber: "DEX0002",
+				Action:       "inference",
+				Priority:     2,
+				Parameters:   map[string]string{"inference_name": "inf1", "count": "3", "dry": "true"},
+				Station:      "stationB",
+			}, false},
+
+		{"missing separator", "init", nil, true},
+		{"empty serial", ":init", nil, true},
+		{"empty action", "DEX0002:", nil, true},
+		{"empty request id", "DEX0002:init#", nil, true},
+		{"JSON without action", `{"serial": "DEX0002"}`, nil, true},
+		{"JSON with object parameter", `{"serial": "DEX0002", "action": "inference", "parameters": {"inference_name": {"a": 1}}}`, nil, true},
+		{"invalid JSON", `{"serial": "DEX0002",`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePLCActionMessage([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePLCActionMessage(%q) error = %v, wantErr %t", tt.payload, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParsePLCActionMessage(%q) = %+v, want %+v", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePLCAction(t *testing.T) {
+	ah := newTestActionHandler(t)
+
+	tests := []struct {
+		name       string
+		action     string
+		parameters map[string]string
+		station    string
+		want       string
+		wantErr    bool
+	}{
+		{"text grammar unchanged", "I:inference1", nil, "", "I:inference1", false},
+		{"parameters build the command", "inference", map[string]string{"inference_name": "inf1"}, "", "I:inf1", false},
+		{"station is appended", "inference", map[string]string{"inference_name": "inf1"}, "stationB", "I:inf1@stationB", false},
+		{"station on text grammar", "T:traj1", nil, "stationB", "T:traj1@stationB", false},
+		{"station given twice", "I:inf1@stationB", nil, "stationB", "", true},
+		{"unknown catalog entry", "nothing", map[string]string{"a": "1"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plcAction := &PLCActionMessage{Action: tt.action, Parameters: tt.parameters, Station: tt.station}
+			err := ah.NormalizePLCAction(plcAction)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizePLCAction error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err == nil && plcAction.Action != tt.want {
+				t.Fatalf("NormalizePLCAction action = %q, want %q", plcAction.Action, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePLCAction(t *testing.T) {
+	ah := newTestActionHandler(t)
+
+	tests := []struct {
+		action  string
+		wantErr bool
+	}{
+		{"init", false},
+		{"I:inference1", false},
+		{"I:inference1@stationB", false},
+		{"SEQ:T:pick,I:inspect@stationB,T:place", false},
+		{"EXT:I:inference2", false},
+		{"EXT:SEQ:T:pick,T:place", false},
+		{"detectObject", false},
+		{"detectObject:pallet", false},
+
+		{"", true},
+		{"unknownAction", true},
+		{"I:inference1@", true},
+		{"I:inference1@nowhere", true},
+		{"SEQ:T:pick,,T:place", true},
+		{"SEQ:T:pick,cancelOrder", true},
+		{"EXT:cancelOrder", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			err := ah.ValidatePLCAction(&PLCActionMessage{SerialNumber: "DEX0002", Action: tt.action})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidatePLCAction(%q) error = %v, wantErr %t", tt.action, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadCommandDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	catalogWithStation := write("catalog.json", `{"actions": [{"name": "home", "pattern": "home", "order": {"nodes": [{"station": "dock"}]}}]}`)
+	dock := write("dock.json", `{"stations": {"dock": {"x": 0, "y": 0, "theta": 0, "mapId": "floor 0"}}}`)
+
+	tests := []struct {
+		name    string
+		config  AppConfig
+		wantErr bool
+	}{
+		{"built-in catalog without stations", AppConfig{}, false},
+		{"catalog station in registry", AppConfig{ActionCatalogFile: catalogWithStation, StationRegistryFile: dock}, false},
+		{"catalog station missing", AppConfig{ActionCatalogFile: catalogWithStation}, true},
+		{"catalog file missing", AppConfig{ActionCatalogFile: filepath.Join(dir, "missing.json")}, true},
+		{"registry file invalid", AppConfig{StationRegistryFile: write("broken.json", `{"stations":`)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := LoadCommandDefinitions(&tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadCommandDefinitions error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
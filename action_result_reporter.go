@@ -0,0 +1,433 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pendingResultTTL is how long a published command is correlated against robot state messages
+const pendingResultTTL = 30 * time.Minute
+
+// pendingCommand holds a published command waiting for its final action states
+type pendingCommand struct {
+	result     PLCActionResult
+	reportedAt ActionResultStatus
+	createdAt  time.Time
+
+	// Instant action effects that must show in the robot state before the command is FINISHED
+	effects    []instantActionEffect
+	finishedAt time.Time // when all actions were first reported FINISHED
+}
+
+// apiResultLog holds the results reported for a command submitted over the HTTP API
+type apiResultLog struct {
+	results   []PLCActionResult
+	createdAt time.Time
+}
+
+// ActionResultReporter publishes per-command results to the PLC and correlates them with robot state
+type ActionResultReporter struct {
+	mqttClient *MQTTClient
+	topics     *TopicLayout
+
+	pending    map[string][]*pendingCommand // robot ID -> published commands
+	apiResults map[string]*apiResultLog     // request ID -> results of commands submitted over the HTTP API
+	mutex      sync.Mutex
+
+	resultCallback ResultCallback
+}
+
+// ResultCallback is called for every result published to the PLC
+type ResultCallback func(result PLCActionResult)
+
+// NewActionResultReporter creates a new action result reporter
+func NewActionResultReporter(mqttClient *MQTTClient, topics *TopicLayout) *ActionResultReporter {
+	return &ActionResultReporter{
+		mqttClient: mqttClient,
+		topics:     topics,
+		pending:    make(map[string][]*pendingCommand),
+		apiResults: make(map[string]*apiResultLog),
+	}
+}
+
+// SetResultCallback sets the callback invoked for every published result
+func (arr *ActionResultReporter) SetResultCallback(callback ResultCallback) {
+	arr.resultCallback = callback
+}
+
+// ReportAccepted publishes an ACCEPTED result for a validated PLC command
+func (arr *ActionResultReporter) ReportAccepted(plcAction *PLCActionMessage) PLCActionResult {
+	return arr.publish(&PLCActionResult{
+		SerialNumber: plcAction.SerialNumber,
+		Action:       plcAction.Action,
+		RequestID:    plcAction.RequestID,
+		Status:       ResultAccepted,
+		reply:        plcAction.Reply,
+	})
+}
+
+// ReportQueued publishes a QUEUED result for a command held back with the given reason
+func (arr *ActionResultReporter) ReportQueued(plcAction *PLCActionMessage, reason string) PLCActionResult {
+	return arr.publish(&PLCActionResult{
+		SerialNumber: plcAction.SerialNumber,
+		Action:       plcAction.Action,
+		RequestID:    plcAction.RequestID,
+		Status:       ResultQueued,
+		Reason:       reason,
+		reply:        plcAction.Reply,
+	})
+}
+
+// ReportWaiting publishes a QUEUED result for a command waiting for its robot to finish the current order
+func (arr *ActionResultReporter) ReportWaiting(plcAction *PLCActionMessage, waiting *WaitingCommand) PLCActionResult {
+	return arr.publish(&PLCActionResult{
+		SerialNumber: plcAction.SerialNumber,
+		Action:       plcAction.Action,
+		RequestID:    plcAction.RequestID,
+		Status:       ResultQueued,
+		Reason:       waiting.WaitReason(),
+		WaitingID:    waiting.ID,
+		reply:        plcAction.Reply,
+	})
+}
+
+// ReportRejected publishes a REJECTED result with the parse or validation error
+func (arr *ActionResultReporter) ReportRejected(plcAction *PLCActionMessage, err error) PLCActionResult {
+	return arr.publish(&PLCActionResult{
+		SerialNumber: plcAction.SerialNumber,
+		Action:       plcAction.Action,
+		RequestID:    plcAction.RequestID,
+		Status:       ResultRejected,
+		Reason:       err.Error(),
+		reply:        plcAction.Reply,
+	})
+}
+
+// ReportFailed publishes a FAILED result for a command that could not be delivered
+func (arr *ActionResultReporter) ReportFailed(plcAction *PLCActionMessage, err error) PLCActionResult {
+	return arr.publish(&PLCActionResult{
+		SerialNumber: plcAction.SerialNumber,
+		Action:       plcAction.Action,
+		RequestID:    plcAction.RequestID,
+		Status:       ResultFailed,
+		Reason:       err.Error(),
+		reply:        plcAction.Reply,
+	})
+}
+
+// ReportDuplicate repeats the latest result of the original request for a redelivered request id
+func (arr *ActionResultReporter) ReportDuplicate(plcAction *PLCActionMessage, original PLCActionResult) PLCActionResult {
+	original.Duplicate = true
+	original.reply = plcAction.Reply
+	return arr.publish(&original)
+}
+
+// ReportPublished publishes a PUBLISHED result and starts correlating the generated ids with robot state
+func (arr *ActionResultReporter) ReportPublished(plcAction *PLCActionMessage, command *RobotCommand) PLCActionResult {
+	result := newPublishedResult(plcAction, command)
+	arr.publish(&result)
+	arr.track(result, command)
+	return result
+}
+
+// ReportBroadcast publishes one result for a group or fleet command with the outcome per robot
+// The overall status is PUBLISHED if any robot got the command, else QUEUED if any waits, else FAILED
+func (arr *ActionResultReporter) ReportBroadcast(plcAction *PLCActionMessage, robots []RobotActionResult) PLCActionResult {
+	counts := make(map[ActionResultStatus]int)
+	for _, robot := range robots {
+		counts[robot.Status]++
+	}
+
+	status := ResultFailed
+	switch {
+	case counts[ResultPublished] > 0:
+		status = ResultPublished
+	case counts[ResultQueued] > 0:
+		status = ResultQueued
+	}
+
+	reason := ""
+	if counts[status] != len(robots) {
+		var parts []string
+		for _, s := range []ActionResultStatus{ResultPublished, ResultQueued, ResultRejected, ResultFailed} {
+			if counts[s] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+			}
+		}
+		reason = fmt.Sprintf("%d robots: %s", len(robots), strings.Join(parts, ", "))
+	}
+
+	return arr.publish(&PLCActionResult{
+		SerialNumber: plcAction.SerialNumber,
+		Action:       plcAction.Action,
+		RequestID:    plcAction.RequestID,
+		Status:       status,
+		Reason:       reason,
+		Robots:       robots,
+		reply:        plcAction.Reply,
+	})
+}
+
+// TrackPublished starts correlating a published command with robot state without publishing a result
+// and returns its PUBLISHED result
+func (arr *ActionResultReporter) TrackPublished(plcAction *PLCActionMessage, command *RobotCommand) PLCActionResult {
+	result := newPublishedResult(plcAction, command)
+	arr.track(result, command)
+	return result
+}
+
+// newPublishedResult builds the PUBLISHED result of a command with its generated ids
+func newPublishedResult(plcAction *PLCActionMessage, command *RobotCommand) PLCActionResult {
+	return PLCActionResult{
+		SerialNumber: plcAction.SerialNumber,
+		Action:       plcAction.Action,
+		RequestID:    plcAction.RequestID,
+		Status:       ResultPublished,
+		OrderID:      command.OrderID(),
+		ActionIDs:    command.ActionIDs(),
+		reply:        plcAction.Reply,
+	}
+}
+
+// track remembers a published command until its actions reach a terminal state
+func (arr *ActionResultReporter) track(result PLCActionResult, command *RobotCommand) {
+	if len(result.ActionIDs) == 0 {
+		return
+	}
+
+	header := command.Header()
+	robotID := makeRobotID(header.Manufacturer, header.SerialNumber)
+
+	arr.mutex.Lock()
+	defer arr.mutex.Unlock()
+	arr.pending[robotID] = append(arr.pending[robotID], &pendingCommand{
+		result:     result,
+		reportedAt: ResultPublished,
+		createdAt:  time.Now(),
+		effects:    instantActionEffectsOf(command),
+	})
+}
+
+// HandleRobotState correlates pending commands with the action states reported by the robot
+func (arr *ActionResultReporter) HandleRobotState(stateMsg *RobotStateMessage) {
+	robotID := makeRobotID(stateMsg.Manufacturer, stateMsg.SerialNumber)
+
+	arr.mutex.Lock()
+	commands := arr.pending[robotID]
+	if len(commands) == 0 {
+		arr.mutex.Unlock()
+		return
+	}
+
+	actionStates := make(map[string]ActionState, len(stateMsg.ActionStates))
+	for _, actionState := range stateMsg.ActionStates {
+		actionStates[actionState.ActionID] = actionState
+	}
+
+	var updates []PLCActionResult
+	var remaining []*pendingCommand
+	for _, command := range commands {
+		status, reason := aggregateActionStatus(command.result.ActionIDs, actionStates)
+		if status == ResultFinished {
+			status, reason = command.confirmEffects(stateMsg)
+		}
+		if status != "" && status != command.reportedAt {
+			command.reportedAt = status
+			update := command.result
+			update.Status = status
+			update.Reason = reason
+			updates = append(updates, update)
+		}
+
+		// Keep the command until it reaches a terminal state or expires
+		if command.reportedAt == ResultFinished || command.reportedAt == ResultFailed {
+			continue
+		}
+		if time.Since(command.createdAt) > pendingResultTTL {
+			log.Printf("⚠️  명령 결과 추적 만료 - Robot: %s, Action: %s", robotID, command.result.Action)
+			continue
+		}
+		remaining = append(remaining, command)
+	}
+
+	if len(remaining) > 0 {
+		arr.pending[robotID] = remaining
+	} else {
+		delete(arr.pending, robotID)
+	}
+	arr.mutex.Unlock()
+
+	// Publish outside the lock
+	for i := range updates {
+		arr.publish(&updates[i])
+	}
+}
+
+// confirmEffects checks the robot state for the effects of finished instant actions
+// The command stays RUNNING until the effect shows, and FAILED if it does not show in time
+func (pc *pendingCommand) confirmEffects(stateMsg *RobotStateMessage) (ActionResultStatus, string) {
+	effect, pending := unconfirmedEffect(pc.effects, stateMsg)
+	if !pending {
+		return ResultFinished, ""
+	}
+
+	if pc.finishedAt.IsZero() {
+		pc.finishedAt = time.Now()
+	}
+	if time.Since(pc.finishedAt) > effectConfirmTimeout {
+		return ResultFailed, fmt.Sprintf("%s finished but robot state is not %s", effect.actionType, effect.description)
+	}
+	return ResultRunning, ""
+}
+
+// HandleOrderStatusChange reports FAILED for commands whose order vanished or was replaced on the robot
+func (arr *ActionResultReporter) HandleOrderStatusChange(order TrackedOrder, oldStatus, newStatus OrderLifecycleStatus) {
+	if newStatus != OrderVanished && newStatus != OrderReplaced {
+		return
+	}
+
+	robotID := makeRobotID(order.Manufacturer, order.SerialNumber)
+
+	arr.mutex.Lock()
+	var failed *PLCActionResult
+	commands := arr.pending[robotID]
+	for i, command := range commands {
+		if command.result.OrderID == order.OrderID {
+			update := command.result
+			update.Status = ResultFailed
+			update.Reason = order.Reason
+			failed = &update
+			arr.pending[robotID] = append(commands[:i:i], commands[i+1:]...)
+			break
+		}
+	}
+	arr.mutex.Unlock()
+
+	if failed != nil {
+		arr.publish(failed)
+	}
+}
+
+// aggregateActionStatus derives a single result status from the states of all actions of a command
+func aggregateActionStatus(actionIDs []string, actionStates map[string]ActionState) (ActionResultStatus, string) {
+	finished := 0
+	running := false
+	for _, actionID := range actionIDs {
+		actionState, exists := actionStates[actionID]
+		if !exists {
+			continue
+		}
+
+		switch actionState.ActionStatus {
+		case "FAILED":
+			return ResultFailed, actionState.ResultDescription
+		case "FINISHED":
+			finished++
+		case "INITIALIZING", "RUNNING", "PAUSED":
+			running = true
+		}
+	}
+
+	if finished == len(actionIDs) {
+		return ResultFinished, ""
+	}
+	if running || finished > 0 {
+		return ResultRunning, ""
+	}
+	return "", ""
+}
+
+// publish sends a result message to the configured result topic and returns it
+// Results of HTTP API commands are kept for the API instead, so the PLC never sees commands it did not send
+func (arr *ActionResultReporter) publish(result *PLCActionResult) PLCActionResult {
+	result.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	if arr.resultCallback != nil {
+		arr.resultCallback(*result)
+	}
+
+	if result.reply != nil && result.reply.API {
+		arr.recordAPIResult(*result)
+		log.Printf("📝 API 명령 결과 기록 - RequestID: %s, Serial: %s, Status: %s", result.RequestID, result.SerialNumber, result.Status)
+		return *result
+	}
+
+	payload, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("❌ 명령 결과 JSON 변환 실패: %v", err)
+		return *result
+	}
+
+	topic := arr.topics.PLCResultTopic(result.SerialNumber)
+	if err := arr.mqttClient.Publish(topic, payload); err != nil {
+		log.Printf("❌ 명령 결과 발행 실패 - Topic: %s, Status: %s, Error: %v", topic, result.Status, err)
+	} else {
+		log.Printf("📤 명령 결과 발행 - Topic: %s, Serial: %s, Status: %s", topic, result.SerialNumber, result.Status)
+	}
+
+	// The MQTT 5 requester waits on its response topic, whether or not the result topic publish succeeded
+	if result.reply != nil {
+		arr.publishResponse(result.reply, payload)
+	}
+	return *result
+}
+
+// recordAPIResult appends a result to the log of its HTTP API request, forgetting logs older than pendingResultTTL
+func (arr *ActionResultReporter) recordAPIResult(result PLCActionResult) {
+	arr.mutex.Lock()
+	defer arr.mutex.Unlock()
+
+	now := time.Now()
+	for requestID, entry := range arr.apiResults {
+		if now.Sub(entry.createdAt) > pendingResultTTL {
+			delete(arr.apiResults, requestID)
+		}
+	}
+
+	entry, exists := arr.apiResults[result.RequestID]
+	if !exists {
+		entry = &apiResultLog{createdAt: now}
+		arr.apiResults[result.RequestID] = entry
+	}
+	entry.results = append(entry.results, result)
+}
+
+// APIResults returns the results reported so far for a command submitted over the HTTP API, oldest first
+func (arr *ActionResultReporter) APIResults(requestID string) ([]PLCActionResult, bool) {
+	arr.mutex.Lock()
+	defer arr.mutex.Unlock()
+
+	entry, exists := arr.apiResults[requestID]
+	if !exists {
+		return nil, false
+	}
+	return append([]PLCActionResult(nil), entry.results...), true
+}
+
+// publishResponse answers an MQTT 5 request on its response topic with the request correlation data
+func (arr *ActionResultReporter) publishResponse(reply *ResponseTarget, payload []byte) {
+	options := PublishOptions{
+		CorrelationData: reply.CorrelationData,
+		UserProperties:  reply.UserProperties,
+	}
+	if err := arr.mqttClient.PublishWithOptions(reply.Topic, payload, options); err != nil {
+		log.Printf("❌ 명령 응답 발행 실패 - Topic: %s, Error: %v", reply.Topic, err)
+		return
+	}
+	log.Printf("📤 명령 응답 발행 - Topic: %s", reply.Topic)
+}
+
+// newResponseTarget returns the response target of an MQTT 5 request, or nil if it has no response topic
+func newResponseTarget(msg *Message) *ResponseTarget {
+	if msg.ResponseTopic == "" {
+		return nil
+	}
+	return &ResponseTarget{
+		Topic:           msg.ResponseTopic,
+		CorrelationData: msg.CorrelationData,
+		UserProperties:  msg.UserProperties,
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport records published topics and fails publishes to the topics in failTopics
+type recordingTransport struct {
+	failTopics map[string]bool
+	mutex      sync.Mutex
+	published  []string
+}
+
+func (rt *recordingTransport) Connect(timeout time.Duration) error { return nil }
+func (rt *recordingTransport) IsConnected() bool                   { return true }
+func (rt *recordingTransport) Disconnect()                         {}
+
+func (rt *recordingTransport) Subscribe(topic string, qos byte, handler MessageHandler) error {
+	return nil
+}
+
+func (rt *recordingTransport) Publish(topic string, qos byte, payload []byte, options PublishOptions) error {
+	if rt.failTopics[topic] {
+		return errors.New("publish rejected")
+	}
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	rt.published = append(rt.published, topic)
+	return nil
+}
+
+func TestActionResultReporterResponseTopic(t *testing.T) {
+	topics, err := NewTopicLayout(newTestTopicConfig("{interfaceName}/{majorVersion}/{manufacturer}/{serialNumber}/{topic}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resultTopic := topics.PLCResultTopic("DEX0001")
+
+	tests := []struct {
+		name          string
+		reply         *ResponseTarget
+		failTopics    map[string]bool
+		wantPublished []string
+	}{
+		{"result topic only", nil, nil, []string{resultTopic}},
+		{"result and response topic", &ResponseTarget{Topic: "plc/responses"}, nil,
+			[]string{resultTopic, "plc/responses"}},
+		{"response despite failed result publish", &ResponseTarget{Topic: "plc/responses"},
+			map[string]bool{resultTopic: true}, []string{"plc/responses"}},
+		{"result despite failed response publish", &ResponseTarget{Topic: "plc/responses"},
+			map[string]bool{"plc/responses": true}, []string{resultTopic}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &recordingTransport{failTopics: tt.failTopics}
+			client := &MQTTClient{client: transport, config: &MQTTConfig{Name: "test"}}
+			reporter := NewActionResultReporter(client, topics)
+
+			reporter.ReportAccepted(&PLCActionMessage{SerialNumber: "DEX0001", Action: "init", Reply: tt.reply})
+			if !reflect.DeepEqual(transport.published, tt.wantPublished) {
+				t.Fatalf("published to %v, want %v", transport.published, tt.wantPublished)
+			}
+		})
+	}
+}
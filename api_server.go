@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIServer exposes bridge status and command dispatch over HTTP
+type APIServer struct {
+	bridge *MQTTBridge
+	server *http.Server
+}
+
+// apiActionRequest represents the body of POST /robots/{robot}/actions
+type apiActionRequest struct {
+	Action    string `json:"action"`
+	RequestID string `json:"requestId,omitempty"` // Optional; generated if empty, results are read back with it
+}
+
+// apiError represents an error response body
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// NewAPIServer creates a new HTTP API server for the bridge
+func NewAPIServer(bridge *MQTTBridge, listenAddr string) *APIServer {
+	as := &APIServer{bridge: bridge}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", as.handleGetStatus)
+	mux.HandleFunc("GET /robots", as.handleGetRobots)
+	mux.HandleFunc("GET /robots/{robot}", as.handleGetRobot)
+	mux.HandleFunc("GET /robots/{robot}/factsheet", as.handleGetRobotFactsheet)
+	mux.HandleFunc("GET /robots/{robot}/orders", as.handleGetRobotOrders)
+	mux.HandleFunc("POST /robots/{robot}/actions", as.handlePostAction)
+	mux.HandleFunc("POST /robots/{robot}/factsheet", as.handlePostFactsheetRequest)
+	mux.HandleFunc("GET /robots/{robot}/queue", as.handleGetRobotQueue)
+	mux.HandleFunc("DELETE /robots/{robot}/queue/{id}", as.handleDeleteQueuedCommand)
+	mux.HandleFunc("GET /orders/{orderId}", as.handleGetOrder)
+	mux.HandleFunc("GET /actions/{requestId}", as.handleGetActionResults)
+
+	as.server = &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return as
+}
+
+// Start begins serving HTTP requests in the background
+func (as *APIServer) Start() error {
+	listener, err := net.Listen("tcp", as.server.Addr)
+	if err != nil {
+		return fmt.Errorf("HTTP 리스너 생성 실패: %w", err)
+	}
+
+	go func() {
+		if err := as.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("❌ HTTP API 서버 오류: %v", err)
+		}
+	}()
+
+	log.Printf("🌐 HTTP API 서버 시작 - Addr: %s", as.server.Addr)
+	return nil
+}
+
+// Stop gracefully shuts down the HTTP server
+func (as *APIServer) Stop(ctx context.Context) {
+	if err := as.server.Shutdown(ctx); err != nil {
+		log.Printf("⚠️  HTTP API 서버 종료 실패: %v", err)
+		return
+	}
+	log.Printf("✅ HTTP API 서버 종료 완료")
+}
+
+// handleGetStatus returns the overall bridge status
+func (as *APIServer) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, as.bridge.GetBridgeStatus())
+}
+
+// handleGetRobots returns the status of all known robots sorted by robot ID
+func (as *APIServer) handleGetRobots(w http.ResponseWriter, r *http.Request) {
+	robots := as.bridge.GetRobotManager().GetAllRobots()
+
+	result := make([]*RobotStatus, 0, len(robots))
+	for _, robot := range robots {
+		result = append(result, robot)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RobotID < result[j].RobotID
+	})
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// resolveRobotID resolves the {robot} path value ("serial" or URL-escaped "manufacturer/serial")
+// and writes a 404 response if it cannot be resolved
+func (as *APIServer) resolveRobotID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	robotID, err := as.bridge.GetRobotManager().ResolveRobotID(r.PathValue("robot"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return "", false
+	}
+	return robotID, true
+}
+
+// handleGetRobot returns the status of a single robot
+func (as *APIServer) handleGetRobot(w http.ResponseWriter, r *http.Request) {
+	robotID, ok := as.resolveRobotID(w, r)
+	if !ok {
+		return
+	}
+
+	robot, exists := as.bridge.GetRobotManager().GetRobotStatus(robotID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("robot %s not found", robotID))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, robot)
+}
+
+// handleGetRobotFactsheet returns the latest factsheet received from a robot
+func (as *APIServer) handleGetRobotFactsheet(w http.ResponseWriter, r *http.Request) {
+	robotID, ok := as.resolveRobotID(w, r)
+	if !ok {
+		return
+	}
+
+	factsheet, exists := as.bridge.GetRobotManager().GetRobotFactsheet(robotID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("factsheet for robot %s not received", robotID))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, factsheet)
+}
+
+// handleGetRobotOrders returns the orders issued to a robot
+func (as *APIServer) handleGetRobotOrders(w http.ResponseWriter, r *http.Request) {
+	robotID, ok := as.resolveRobotID(w, r)
+	if !ok {
+		return
+	}
+
+	orders := as.bridge.GetOrderTracker().GetRobotOrders(robotID)
+	if orders == nil {
+		orders = []TrackedOrder{}
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+// handleGetOrder returns a single tracked order
+func (as *APIServer) handleGetOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("orderId")
+
+	order, exists := as.bridge.GetOrderTracker().GetOrder(orderID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("order %s not found", orderID))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// handlePostAction dispatches an action using the same grammar and result tracking as bridge/actions
+// Body: {"action": "I:inference1", "requestId": "..."} or the plain action string
+// Commands for a busy robot follow the action's busy policy and may be answered with QUEUED;
+// later results (RUNNING, FINISHED, FAILED) are read from GET /actions/{requestId}
+func (as *APIServer) handlePostAction(w http.ResponseWriter, r *http.Request) {
+	target := r.PathValue("robot")
+
+	request, err := readActionRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if request.RequestID == "" {
+		request.RequestID = newAPIRequestID()
+	}
+
+	// Results go to the API only, never to the PLC result topic
+	plcAction := &PLCActionMessage{
+		Action:       request.Action,
+		SerialNumber: target,
+		RequestID:    request.RequestID,
+		Reply:        &ResponseTarget{API: true},
+	}
+
+	log.Printf("🌐 HTTP 액션 요청 - Robot: %s, Action: %s, RequestID: %s", target, request.Action, request.RequestID)
+
+	result, err := as.bridge.SubmitAction(plcAction)
+	if err != nil {
+		writeError(w, actionErrorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, result)
+}
+
+// actionErrorStatus maps why a command was rejected or failed to an HTTP status code
+func actionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidCommand):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnknownTarget):
+		return http.StatusNotFound
+	case errors.Is(err, ErrRobotBusy):
+		return http.StatusConflict
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+// newAPIRequestID creates a request id for an HTTP API command that did not bring one
+func newAPIRequestID() string {
+	randomBytes := make([]byte, 8)
+	rand.Read(randomBytes)
+	return fmt.Sprintf("api-%x", randomBytes)
+}
+
+// handleGetActionResults returns the results reported so far for a command submitted over the API
+func (as *APIServer) handleGetActionResults(w http.ResponseWriter, r *http.Request) {
+	requestID := r.PathValue("requestId")
+	results, exists := as.bridge.GetActionResults(requestID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no results for request %s", requestID))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// handleGetRobotQueue returns the commands waiting for a robot to finish its current order
+func (as *APIServer) handleGetRobotQueue(w http.ResponseWriter, r *http.Request) {
+	robotID, ok := as.resolveRobotID(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, as.bridge.GetDispatcher().Waiting(robotID))
+}
+
+// handleDeleteQueuedCommand cancels a command waiting for a robot; its result is reported as FAILED
+func (as *APIServer) handleDeleteQueuedCommand(w http.ResponseWriter, r *http.Request) {
+	robotID, ok := as.resolveRobotID(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid command id '%s'", r.PathValue("id")))
+		return
+	}
+
+	waiting, exists := as.bridge.CancelWaitingCommand(robotID, id)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no command %d waiting for robot %s", id, robotID))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, waiting)
+}
+
+// handlePostFactsheetRequest sends a factsheet request to a robot
+func (as *APIServer) handlePostFactsheetRequest(w http.ResponseWriter, r *http.Request) {
+	robotID, ok := as.resolveRobotID(w, r)
+	if !ok {
+		return
+	}
+
+	robot, exists := as.bridge.GetRobotManager().GetRobotStatus(robotID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("robot %s not found", robotID))
+		return
+	}
+
+	if err := as.bridge.SendFactsheetRequest(robot.SerialNumber, robot.Manufacturer); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"robotId": robotID,
+		"status":  string(ResultPublished),
+	})
+}
+
+// readActionRequest extracts the action (and optional request id) from a JSON or plain text body
+func readActionRequest(r *http.Request) (apiActionRequest, error) {
+	var request apiActionRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		return request, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal(body, &request); err != nil {
+			return request, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		trimmed = strings.TrimSpace(request.Action)
+	}
+
+	if trimmed == "" {
+		return request, fmt.Errorf("action is required")
+	}
+	request.Action = trimmed
+	return request, nil
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("❌ HTTP 응답 JSON 변환 실패: %v", err)
+	}
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	writeJSON(w, statusCode, apiError{Error: err.Error()})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// BridgeVersion is the bridge build version, set with -ldflags "-X main.BridgeVersion=..."
+var BridgeVersion = "dev"
+
+// BridgeConnectionMessage mirrors the VDA5050 connection message for the bridge itself
+// ONLINE is published on every connect, OFFLINE on graceful shutdown or by the broker as last will
+type BridgeConnectionMessage struct {
+	HeaderID        int             `json:"headerId"`
+	Timestamp       string          `json:"timestamp"`
+	Version         string          `json:"version"`
+	ClientID        string          `json:"clientId"`
+	ConnectionState ConnectionState `json:"connectionState"`
+}
+
+// BridgeHeartbeatMessage is published periodically while the bridge is running
+type BridgeHeartbeatMessage struct {
+	HeaderID       int    `json:"headerId"`
+	Timestamp      string `json:"timestamp"`
+	Version        string `json:"version"`
+	ClientID       string `json:"clientId"`
+	StartedAt      string `json:"startedAt"`
+	UptimeSec      int64  `json:"uptimeSec"`
+	ReconnectCount int32  `json:"reconnectCount"`
+	OnlineRobots   int    `json:"onlineRobots"`
+}
+
+// bridgeConnectionPayload builds the bridge connection message for the given state
+func (mc *MQTTClient) bridgeConnectionPayload(state ConnectionState) ([]byte, error) {
+	return json.Marshal(BridgeConnectionMessage{
+		HeaderID:        int(atomic.AddInt32(&mc.connectionHeaderID, 1)),
+		Timestamp:       time.Now().UTC().Format(time.RFC3339Nano),
+		Version:         BridgeVersion,
+		ClientID:        mc.config.ClientID,
+		ConnectionState: state,
+	})
+}
+
+// publishBridgeConnection publishes the retained bridge connection state
+func (mc *MQTTClient) publishBridgeConnection(state ConnectionState) {
+	topic := mc.topics.BridgeConnectionTopic()
+	payload, err := mc.bridgeConnectionPayload(state)
+	if err != nil {
+		log.Printf("❌ 브릿지 연결 상태 JSON 변환 실패: %v", err)
+		return
+	}
+
+	if err := mc.PublishRetained(topic, payload); err != nil {
+		log.Printf("❌ [%s] 브릿지 연결 상태 발행 실패 - Topic: %s, State: %s, Error: %v", mc.config.Name, topic, state, err)
+		return
+	}
+
+	log.Printf("📡 [%s] 브릿지 연결 상태 발행 - Topic: %s, State: %s", mc.config.Name, topic, state)
+}
+
+// BridgeHeartbeat periodically publishes bridge uptime and version
+type BridgeHeartbeat struct {
+	bridge    *MQTTBridge
+	topic     string
+	interval  time.Duration
+	startedAt time.Time
+	headerID  int
+}
+
+// NewBridgeHeartbeat creates a new bridge heartbeat publisher
+func NewBridgeHeartbeat(bridge *MQTTBridge, topic string, interval time.Duration) *BridgeHeartbeat {
+	return &BridgeHeartbeat{
+		bridge:    bridge,
+		topic:     topic,
+		interval:  interval,
+		startedAt: time.Now(),
+	}
+}
+
+// Run publishes a heartbeat on every interval until the context is cancelled
+func (bh *BridgeHeartbeat) Run(ctx context.Context) {
+	ticker := time.NewTicker(bh.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			bh.publish()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publish sends a single heartbeat
+func (bh *BridgeHeartbeat) publish() {
+	mqttClient := bh.bridge.GetMQTTClient()
+	if !mqttClient.IsConnected() {
+		return
+	}
+
+	now := time.Now()
+	bh.headerID++
+	payload, err := json.Marshal(BridgeHeartbeatMessage{
+		HeaderID:       bh.headerID,
+		Timestamp:      now.UTC().Format(time.RFC3339Nano),
+		Version:        BridgeVersion,
+		ClientID:       mqttClient.config.ClientID,
+		StartedAt:      bh.startedAt.UTC().Format(time.RFC3339Nano),
+		UptimeSec:      int64(now.Sub(bh.startedAt).Seconds()),
+		ReconnectCount: mqttClient.GetReconnectCount(),
+		OnlineRobots:   len(bh.bridge.GetRobotManager().GetOnlineRobots()),
+	})
+	if err != nil {
+		log.Printf("❌ 하트비트 JSON 변환 실패: %v", err)
+		return
+	}
+
+	if err := mqttClient.Publish(bh.topic, payload); err != nil {
+		log.Printf("❌ 하트비트 발행 실패 - Topic: %s, Error: %v", bh.topic, err)
+	}
+}
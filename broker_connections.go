@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// BrokerConnections holds the named broker connections and routes PLC and robot traffic to them
+// The PLC side uses exactly one connection; robot commands go to the connection the robot last reported on
+type BrokerConnections struct {
+	clients      []*MQTTClient // in configuration order
+	plcClient    *MQTTClient
+	robotClients []*MQTTClient
+
+	robotHomes map[string]*MQTTClient // robot ID -> connection its messages arrive on
+	mutex      sync.RWMutex
+}
+
+// ConnectionInfo is the status of one broker connection
+type ConnectionInfo struct {
+	Name            string            `json:"name"`
+	BrokerURL       string            `json:"brokerUrl"`
+	ClientID        string            `json:"clientId"`
+	ServesPLC       bool              `json:"servesPlc"`
+	ServesRobots    bool              `json:"servesRobots"`
+	ProtocolVersion int               `json:"protocolVersion"`
+	Status          ConnectionStatus  `json:"status"`
+	ReconnectCount  int32             `json:"reconnectCount"`
+	LastReason      *ConnectionReason `json:"lastReason,omitempty"` // MQTT 5 CONNACK/DISCONNECT reason code
+}
+
+// NewBrokerConnections creates an MQTT client for every configured connection (without handlers initially)
+func NewBrokerConnections(configs []MQTTConfig, topics *TopicLayout) (*BrokerConnections, error) {
+	bc := &BrokerConnections{
+		robotHomes: make(map[string]*MQTTClient),
+	}
+
+	for i := range configs {
+		client, err := NewMQTTClient(&configs[i], topics, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s 연결 클라이언트 생성 실패: %w", configs[i].Name, err)
+		}
+		bc.clients = append(bc.clients, client)
+		if client.config.ServesPLC {
+			bc.plcClient = client
+		}
+		if client.config.ServesRobots {
+			bc.robotClients = append(bc.robotClients, client)
+		}
+	}
+
+	return bc, nil
+}
+
+// SetHandlers sets the message handlers of all connections
+func (bc *BrokerConnections) SetHandlers(handlers *MessageHandlers) {
+	for _, client := range bc.clients {
+		client.handlers = handlers
+	}
+}
+
+// SetStatusCallback sets the status callback of all connections
+func (bc *BrokerConnections) SetStatusCallback(callback ConnectionStatusCallback) {
+	for _, client := range bc.clients {
+		client.SetStatusCallback(callback)
+	}
+}
+
+// Connect connects all connections in configuration order
+func (bc *BrokerConnections) Connect() error {
+	for _, client := range bc.clients {
+		if err := client.Connect(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop gracefully disconnects all connections
+func (bc *BrokerConnections) Stop() {
+	for _, client := range bc.clients {
+		client.Stop()
+	}
+}
+
+// PLC returns the connection serving the PLC side
+func (bc *BrokerConnections) PLC() *MQTTClient {
+	return bc.plcClient
+}
+
+// ForRobot returns the connection to send commands to a robot on
+func (bc *BrokerConnections) ForRobot(robotID string) *MQTTClient {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+
+	if client, exists := bc.robotHomes[robotID]; exists {
+		return client
+	}
+	return bc.robotClients[0]
+}
+
+// NoteRobot remembers the connection a robot's messages arrived on
+func (bc *BrokerConnections) NoteRobot(robotID string, connection string) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	previous := bc.robotHomes[robotID]
+	if previous != nil && previous.config.Name == connection {
+		return
+	}
+	for _, client := range bc.robotClients {
+		if client.config.Name == connection {
+			bc.robotHomes[robotID] = client
+			if previous != nil {
+				log.Printf("🔀 로봇 브로커 연결 변경 - Robot: %s, %s -> %s", robotID, previous.config.Name, connection)
+			}
+			return
+		}
+	}
+}
+
+// RobotsConnected reports whether at least one robot side connection is connected
+func (bc *BrokerConnections) RobotsConnected() bool {
+	for _, client := range bc.robotClients {
+		if client.IsConnected() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsConnected reports whether all connections are connected
+func (bc *BrokerConnections) IsConnected() bool {
+	for _, client := range bc.clients {
+		if !client.IsConnected() {
+			return false
+		}
+	}
+	return true
+}
+
+// GetConnectionStatus returns CONNECTED if all connections are connected, else the status of the first one that is not
+func (bc *BrokerConnections) GetConnectionStatus() ConnectionStatus {
+	for _, client := range bc.clients {
+		if status := client.GetConnectionStatus(); status != Connected {
+			return status
+		}
+	}
+	return Connected
+}
+
+// GetReconnectCount returns the number of reconnections over all connections
+func (bc *BrokerConnections) GetReconnectCount() int32 {
+	var total int32
+	for _, client := range bc.clients {
+		total += client.GetReconnectCount()
+	}
+	return total
+}
+
+// GetConnectionInfos returns the status of every connection in configuration order
+func (bc *BrokerConnections) GetConnectionInfos() []ConnectionInfo {
+	infos := make([]ConnectionInfo, 0, len(bc.clients))
+	for _, client := range bc.clients {
+		infos = append(infos, ConnectionInfo{
+			Name:            client.config.Name,
+			BrokerURL:       client.config.BrokerURL,
+			ClientID:        client.config.ClientID,
+			ServesPLC:       client.config.ServesPLC,
+			ServesRobots:    client.config.ServesRobots,
+			ProtocolVersion: client.config.ProtocolVersion,
+			Status:          client.GetConnectionStatus(),
+			ReconnectCount:  client.GetReconnectCount(),
+			LastReason:      client.GetLastReason(),
+		})
+	}
+	return infos
+}
+
+// String describes the connection for logs
+func (ci ConnectionInfo) String() string {
+	roles := ""
+	switch {
+	case ci.ServesPLC && ci.ServesRobots:
+		roles = "PLC+Robots"
+	case ci.ServesPLC:
+		roles = "PLC"
+	default:
+		roles = "Robots"
+	}
+	return fmt.Sprintf("%s [%s] %s (재연결: %d회)", ci.Name, roles, ci.Status, ci.ReconnectCount)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// QueuedCommand is a PLC command waiting for the robot side broker connection
+type QueuedCommand struct {
+	ID        int64            `json:"id"`
+	Action    PLCActionMessage `json:"action"`
+	Reply     *ResponseTarget  `json:"reply,omitempty"`
+	QueuedAt  time.Time        `json:"queuedAt"`
+	ExpiresAt time.Time        `json:"expiresAt"`
+}
+
+// commandQueueRecord is one line of the append-only queue file
+type commandQueueRecord struct {
+	Op      string         `json:"op"` // "add" or "remove"
+	ID      int64          `json:"id,omitempty"`
+	Command *QueuedCommand `json:"command,omitempty"`
+}
+
+// CommandQueue keeps PLC commands received while the robot side is disconnected, in arrival order,
+// in an append-only JSON lines file so that they survive a bridge restart
+// The file is compacted on load and truncated whenever the queue becomes empty
+type CommandQueue struct {
+	path     string
+	ttl      time.Duration
+	file     *os.File
+	commands []*QueuedCommand
+	nextID   int64
+	mutex    sync.Mutex
+}
+
+// NewCommandQueue opens the queue file and loads the commands still pending in it
+func NewCommandQueue(path string, ttl time.Duration) (*CommandQueue, error) {
+	cq := &CommandQueue{
+		path:   path,
+		ttl:    ttl,
+		nextID: 1,
+	}
+	if err := cq.load(); err != nil {
+		return nil, err
+	}
+	if err := cq.rewrite(); err != nil {
+		return nil, err
+	}
+
+	if len(cq.commands) > 0 {
+		log.Printf("📦 대기 중인 명령 로드 - File: %s, Commands: %d개", path, len(cq.commands))
+	}
+	return cq, nil
+}
+
+// load replays the queue file
+func (cq *CommandQueue) load() error {
+	file, err := os.Open(cq.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read command queue: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record commandQueueRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A crash can leave a partial last line; everything before it is intact
+			log.Printf("⚠️  명령 큐 파일 손상된 줄 무시 - File: %s, Line: %d, Error: %v", cq.path, line, err)
+			continue
+		}
+
+		switch {
+		case record.Op == "add" && record.Command != nil:
+			cq.commands = append(cq.commands, record.Command)
+			cq.nextID = max(cq.nextID, record.Command.ID+1)
+		case record.Op == "remove":
+			cq.removeLocked(record.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read command queue %s: %w", cq.path, err)
+	}
+	return nil
+}
+
+// rewrite replaces the queue file with the pending commands and reopens it for appending
+func (cq *CommandQueue) rewrite() error {
+	if cq.file != nil {
+		cq.file.Close()
+		cq.file = nil
+	}
+
+	var data []byte
+	for _, command := range cq.commands {
+		line, err := json.Marshal(commandQueueRecord{Op: "add", Command: command})
+		if err != nil {
+			return fmt.Errorf("failed to encode queued command: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := writeFileAtomic(cq.path, data); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(cq.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open command queue: %w", err)
+	}
+	cq.file = file
+	return nil
+}
+
+// append writes a record and syncs it to disk
+func (cq *CommandQueue) append(record commandQueueRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode command queue record: %w", err)
+	}
+	if _, err := cq.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write command queue: %w", err)
+	}
+	return cq.file.Sync()
+}
+
+// Enqueue stores a command at the end of the queue with the queue TTL
+func (cq *CommandQueue) Enqueue(plcAction *PLCActionMessage) (*QueuedCommand, error) {
+	cq.mutex.Lock()
+	defer cq.mutex.Unlock()
+
+	now := time.Now()
+	command := &QueuedCommand{
+		ID:        cq.nextID,
+		Action:    *plcAction,
+		Reply:     plcAction.Reply,
+		QueuedAt:  now,
+		ExpiresAt: now.Add(cq.ttl),
+	}
+	if err := cq.append(commandQueueRecord{Op: "add", Command: command}); err != nil {
+		return nil, err
+	}
+
+	cq.nextID++
+	cq.commands = append(cq.commands, command)
+	return command, nil
+}
+
+// Remove deletes a delivered or expired command from the queue
+func (cq *CommandQueue) Remove(id int64) error {
+	cq.mutex.Lock()
+	defer cq.mutex.Unlock()
+
+	if !cq.removeLocked(id) {
+		return nil
+	}
+	if len(cq.commands) == 0 {
+		// Nothing pending: start over with an empty file instead of growing it forever
+		return cq.rewrite()
+	}
+	return cq.append(commandQueueRecord{Op: "remove", ID: id})
+}
+
+// removeLocked deletes a command from memory and reports whether it was queued
+func (cq *CommandQueue) removeLocked(id int64) bool {
+	for i, command := range cq.commands {
+		if command.ID == id {
+			cq.commands = append(cq.commands[:i], cq.commands[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Pending returns the queued commands in arrival order
+func (cq *CommandQueue) Pending() []QueuedCommand {
+	cq.mutex.Lock()
+	defer cq.mutex.Unlock()
+
+	commands := make([]QueuedCommand, len(cq.commands))
+	for i, command := range cq.commands {
+		commands[i] = *command
+	}
+	return commands
+}
+
+// HasPending reports whether commands for any of the robots are waiting, so new ones queue behind them
+// robotIDs resolves the target of a queued command to the IDs of the robots it addresses
+func (cq *CommandQueue) HasPending(robots []string, robotIDs func(target string) []string) bool {
+	cq.mutex.Lock()
+	defer cq.mutex.Unlock()
+
+	for _, command := range cq.commands {
+		for _, robotID := range robotIDs(command.Action.SerialNumber) {
+			if slices.Contains(robots, robotID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Len returns the number of queued commands
+func (cq *CommandQueue) Len() int {
+	cq.mutex.Lock()
+	defer cq.mutex.Unlock()
+	return len(cq.commands)
+}
+
+// Close closes the queue file
+func (cq *CommandQueue) Close() {
+	cq.mutex.Lock()
+	defer cq.mutex.Unlock()
+
+	if cq.file != nil {
+		cq.file.Close()
+		cq.file = nil
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pendingActions returns the actions of the queued commands in queue order
+func pendingActions(cq *CommandQueue) []string {
+	var actions []string
+	for _, command := range cq.Pending() {
+		actions = append(actions, command.Action.Action)
+	}
+	return actions
+}
+
+func TestCommandQueueReplay(t *testing.T) {
+	tests := []struct {
+		name       string
+		enqueue    []string // actions enqueued before the restart
+		remove     []int64  // IDs removed before the restart
+		appendRaw  string   // written to the file before the restart, e.g. a torn last line
+		wantAfter  []string // pending actions after the restart
+		wantNextID int64    // ID of the next command enqueued after the restart
+	}{
+		{"empty queue", nil, nil, "", nil, 1},
+		{"all pending", []string{"init", "I:a", "T:b"}, nil, "", []string{"init", "I:a", "T:b"}, 4},
+		{"removed in the middle", []string{"init", "I:a", "T:b"}, []int64{2}, "", []string{"init", "T:b"}, 4},
+		{"removed last keeps the ID sequence", []string{"init", "I:a", "T:b"}, []int64{3}, "", []string{"init", "I:a"}, 4},
+		{"unknown ID ignored", []string{"init"}, []int64{42}, "", []string{"init"}, 2},
+		{"torn last line", []string{"init", "I:a"}, nil, `{"op":"add","command":{"id":3,"act`, []string{"init", "I:a"}, 3},
+		{"remove record in the file", []string{"init", "I:a"}, nil, `{"op":"remove","id":1}` + "\n", []string{"I:a"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "queue.jsonl")
+			cq, err := NewCommandQueue(path, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, action := range tt.enqueue {
+				if _, err := cq.Enqueue(&PLCActionMessage{SerialNumber: "DEX0001", Action: action}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, id := range tt.remove {
+				if err := cq.Remove(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cq.Close()
+
+			if tt.appendRaw != "" {
+				file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				file.WriteString(tt.appendRaw)
+				file.Close()
+			}
+
+			reopened, err := NewCommandQueue(path, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reopened.Close()
+
+			if got := pendingActions(reopened); !reflect.DeepEqual(got, tt.wantAfter) {
+				t.Fatalf("pending after restart = %v, want %v", got, tt.wantAfter)
+			}
+			next, err := reopened.Enqueue(&PLCActionMessage{SerialNumber: "DEX0001", Action: "stateRequest"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next.ID != tt.wantNextID {
+				t.Fatalf("next ID after restart = %d, want %d", next.ID, tt.wantNextID)
+			}
+		})
+	}
+}
+
+func TestCommandQueueCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	cq, err := NewCommandQueue(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range []string{"init", "I:a", "T:b"} {
+		if _, err := cq.Enqueue(&PLCActionMessage{SerialNumber: "DEX0001", Action: action}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cq.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	cq.Close()
+
+	// Loading rewrites the file with the pending commands only
+	cq, err = NewCommandQueue(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || strings.Contains(string(data), `"op":"remove"`) {
+		t.Fatalf("compacted file has %d lines:\n%s", len(lines), data)
+	}
+
+	// The file is truncated once the queue is empty
+	for _, command := range cq.Pending() {
+		if err := cq.Remove(command.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cq.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf("queue file size = %d after the queue emptied, want 0", info.Size())
+	}
+}
+
+func TestCommandQueueKeepsReplyTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	cq, err := NewCommandQueue(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := &ResponseTarget{Topic: "plc/responses", CorrelationData: []byte{1, 2}}
+	if _, err := cq.Enqueue(&PLCActionMessage{SerialNumber: "DEX0001", Action: "init", Reply: reply}); err != nil {
+		t.Fatal(err)
+	}
+	cq.Close()
+
+	reopened, err := NewCommandQueue(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	pending := reopened.Pending()
+	if len(pending) != 1 || !reflect.DeepEqual(pending[0].Reply, reply) {
+		t.Fatalf("pending after restart = %+v, want one command replying to %+v", pending, reply)
+	}
+}
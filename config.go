@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -11,8 +14,10 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	App  AppConfig
-	MQTT MQTTConfig
+	App         AppConfig
+	MQTT        MQTTConfig   // 기본 브로커 설정 (이름 있는 연결의 기본값)
+	Connections []MQTTConfig // 브로커 연결 목록 (MQTT_CONNECTIONS가 없으면 MQTT 하나)
+	Topic       TopicConfig
 }
 
 // AppConfig holds application-specific configuration
@@ -21,14 +26,35 @@ type AppConfig struct {
 	LogLevel              string
 	StatusIntervalSeconds int
 	GracefulShutdownSec   int
-	TargetRobotSerials    []string // 관리 대상 로봇 시리얼 번호 목록
+	TargetRobotSerials    []string // 관리 대상 로봇 목록 (serial 또는 manufacturer/serial)
 	AutoInitOnConnect     bool     // 로봇 연결 시 자동 초기화 여부
 	AutoInitDelaySec      int      // 자동 초기화 지연 시간 (초)
+	AutoInitUseLastPose   bool     // 자동 초기화 시 마지막으로 보고된 위치 사용 여부
+	AutoInitMaxPoseAgeSec int      // 자동 초기화에 쓸 마지막 위치의 최대 경과 시간 (초, 0이면 제한 없음, 지나면 초기화 생략)
+	PositionStoreFile     string   // 로봇 마지막 위치 저장 파일 (빈 값이면 메모리에만 보관)
 	AutoFactsheetRequest  bool     // 초기화 후 자동 Factsheet 요청 여부
+	HTTPListenAddr        string   // REST API 서버 주소 (빈 값이면 비활성화)
+	StrictFactsheetCheck  bool     // Factsheet 기반 액션 엄격 검증 여부
+	ActionCatalogFile     string   // PLC 명령 액션 카탈로그 JSON 파일 (빈 값이면 내장 카탈로그)
+	StationRegistryFile   string   // 이름 있는 스테이션 위치 JSON 파일 (빈 값이면 스테이션 없음)
+	HeartbeatIntervalSec  int      // 브릿지 하트비트 발행 주기 (초, 0이면 비활성화)
+	CommandQueueFile      string   // 연결 끊김 중 받은 명령 저장 파일 (빈 값이면 큐 비활성화)
+	CommandQueueTTLSec    int      // 큐에 저장된 명령 유효 시간 (초, 지나면 FAILED 보고)
+	OrderBusyPolicy       string   // 주문 실행 중인 로봇에 새 주문이 오면: reject, queue, preempt, send
+	BusyQueueTTLSec       int      // 로봇 작업 완료를 기다리는 명령 유효 시간 (초, 지나면 FAILED 보고)
+	DedupWindowSec        int      // 같은 PLC 요청 ID를 중복으로 처리하는 기간 (초, 0이면 비활성화)
+
+	// 로봇 그룹 이름 -> 대상 로봇 목록 (PLC 명령 대상 "@그룹이름"), APP_ROBOT_GROUPS + APP_ROBOT_GROUP_<NAME>
+	RobotGroups map[string][]string
 }
 
-// MQTTConfig holds MQTT broker configuration (single client for bridge)
+// MQTTConfig holds the configuration of one MQTT broker connection
 type MQTTConfig struct {
+	Name         string // 연결 이름 (로그, 상태 표시용)
+	ServesPLC    bool   // PLC 명령 수신 및 결과/이벤트/상태 발행 담당
+	ServesRobots bool   // 로봇 토픽 구독 및 로봇 명령 발행 담당
+	envPrefix    string // 설정 오류 메시지용 환경 변수 접두사 (예: MQTT_, MQTT_PLC_)
+
 	BrokerURL            string
 	ClientID             string
 	Username             string
@@ -40,6 +66,28 @@ type MQTTConfig struct {
 	MaxReconnectDelay    int
 	MaxReconnectAttempts int
 	CleanSession         bool
+	ProtocolVersion      int // 4 = MQTT 3.1.1, 5 = MQTT 5
+	CommandExpirySec     int // 로봇 명령 메시지 만료 시간 (초, MQTT 5 전용, 0이면 만료 없음)
+
+	// TLS (ssl://, mqtts://, tls://, tcps://, wss:// 브로커에서 사용, 파일 변경 시 재연결에 자동 반영)
+	TLSCAFile     string // 브로커 인증서 검증용 사설 CA 번들 (빈 값이면 시스템 CA)
+	TLSCertFile   string // 상호 TLS 클라이언트 인증서
+	TLSKeyFile    string // 상호 TLS 클라이언트 개인 키
+	TLSServerName string // 인증서 검증 및 SNI에 사용할 서버 이름 (빈 값이면 브로커 호스트)
+}
+
+// TopicConfig holds the MQTT topic layout for robots and PLC
+type TopicConfig struct {
+	InterfaceName         string // VDA5050 interface name (예: meili, uagv)
+	MajorVersion          string // VDA5050 major version (예: v2)
+	Manufacturer          string // 제조사 세그먼트가 없는 템플릿에서 사용할 기본 제조사
+	RobotTopicTemplate    string // 로봇 토픽 템플릿
+	PLCActionTopic        string // PLC 명령 수신 토픽
+	PLCResultTopic        string // PLC 명령 결과 발행 토픽 ({serial} 치환 가능)
+	RobotEventTopic       string // 로봇 이벤트 발행 토픽 ({serial}, {manufacturer} 치환 가능)
+	FleetStatusTopic      string // 전체 상태 retained 발행 토픽
+	BridgeConnectionTopic string // 브릿지 연결 상태 토픽 (ONLINE 발행, OFFLINE은 LWT로 설정)
+	BridgeHeartbeatTopic  string // 브릿지 하트비트 발행 토픽
 }
 
 // LoadConfig loads configuration from environment variables and .env file
@@ -50,9 +98,17 @@ func LoadConfig() (*Config, error) {
 		fmt.Printf("Warning: .env file not found: %v\n", err)
 	}
 
+	mqttConfig := loadMQTTConfig("MQTT_", defaultMQTTConfig)
+	connections, err := loadMQTTConnections(mqttConfig)
+	if err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
 	config := &Config{
-		App:  loadAppConfig(),
-		MQTT: loadMQTTConfig(),
+		App:         loadAppConfig(),
+		MQTT:        mqttConfig,
+		Connections: connections,
+		Topic:       loadTopicConfig(),
 	}
 
 	if err := validateConfig(config); err != nil {
@@ -72,25 +128,167 @@ func loadAppConfig() AppConfig {
 		TargetRobotSerials:    getEnvStringArray("APP_TARGET_ROBOT_SERIALS", []string{"DEX0001", "DEX0002", "DEX0003"}),
 		AutoInitOnConnect:     getEnvBool("APP_AUTO_INIT_ON_CONNECT", true),
 		AutoInitDelaySec:      getEnvInt("APP_AUTO_INIT_DELAY_SEC", 2),
+		AutoInitUseLastPose:   getEnvBool("APP_AUTO_INIT_USE_LAST_POSE", false),
+		AutoInitMaxPoseAgeSec: getEnvInt("APP_AUTO_INIT_MAX_POSE_AGE_SEC", 3600),
+		PositionStoreFile:     getEnvString("APP_POSITION_STORE_FILE", ""),
 		AutoFactsheetRequest:  getEnvBool("APP_AUTO_FACTSHEET_REQUEST", true),
+		HTTPListenAddr:        getEnvString("APP_HTTP_LISTEN_ADDR", ""),
+		HeartbeatIntervalSec:  getEnvInt("APP_HEARTBEAT_INTERVAL_SEC", 10),
+		StrictFactsheetCheck:  getEnvBool("APP_STRICT_FACTSHEET_CHECK", false),
+		ActionCatalogFile:     getEnvString("APP_ACTION_CATALOG_FILE", ""),
+		StationRegistryFile:   getEnvString("APP_STATION_REGISTRY_FILE", ""),
+		CommandQueueFile:      getEnvString("APP_COMMAND_QUEUE_FILE", ""),
+		CommandQueueTTLSec:    getEnvInt("APP_COMMAND_QUEUE_TTL_SEC", 60),
+		OrderBusyPolicy:       getEnvString("APP_ORDER_BUSY_POLICY", string(BusyQueue)),
+		BusyQueueTTLSec:       getEnvInt("APP_BUSY_QUEUE_TTL_SEC", 600),
+		DedupWindowSec:        getEnvInt("APP_DEDUP_WINDOW_SEC", 300),
+		RobotGroups:           loadRobotGroups(),
+	}
+}
+
+// loadRobotGroups loads the robot groups listed in APP_ROBOT_GROUPS
+// The members of each group are read from APP_ROBOT_GROUP_<NAME> (serial or manufacturer/serial)
+func loadRobotGroups() map[string][]string {
+	groups := make(map[string][]string)
+	for _, name := range getEnvStringArray("APP_ROBOT_GROUPS", nil) {
+		groups[name] = getEnvStringArray("APP_ROBOT_GROUP_"+strings.ToUpper(name), nil)
 	}
+	return groups
+}
+
+// defaultMQTTConfig holds the defaults of the MQTT_* settings
+var defaultMQTTConfig = MQTTConfig{
+	BrokerURL:            "tcp://localhost:1883",
+	ClientID:             "mqtt_robot_bridge",
+	QoS:                  1,
+	KeepAlive:            60,
+	ConnectTimeout:       10,
+	ReconnectDelay:       5,
+	MaxReconnectDelay:    60,
+	MaxReconnectAttempts: 10,
+	CleanSession:         true,
+	ProtocolVersion:      4,
+	CommandExpirySec:     30,
 }
 
-// loadMQTTConfig loads MQTT configuration (single client)
-func loadMQTTConfig() MQTTConfig {
+// loadMQTTConfig loads the MQTT configuration of the environment variables with the given prefix
+func loadMQTTConfig(prefix string, defaults MQTTConfig) MQTTConfig {
 	return MQTTConfig{
-		BrokerURL:            getEnvString("MQTT_BROKER_URL", "tcp://localhost:1883"),
-		ClientID:             getEnvString("MQTT_CLIENT_ID", "mqtt_robot_bridge"),
-		Username:             getEnvString("MQTT_USERNAME", ""),
-		Password:             getEnvString("MQTT_PASSWORD", ""),
-		QoS:                  byte(getEnvInt("MQTT_QOS", 1)),
-		KeepAlive:            getEnvInt("MQTT_KEEP_ALIVE", 60),
-		ConnectTimeout:       getEnvInt("MQTT_CONNECT_TIMEOUT", 10),
-		ReconnectDelay:       getEnvInt("MQTT_RECONNECT_DELAY", 5),
-		MaxReconnectDelay:    getEnvInt("MQTT_MAX_RECONNECT_DELAY", 60),
-		MaxReconnectAttempts: getEnvInt("MQTT_MAX_RECONNECT_ATTEMPTS", 10),
-		CleanSession:         getEnvBool("MQTT_CLEAN_SESSION", true),
+		envPrefix:            prefix,
+		BrokerURL:            getEnvString(prefix+"BROKER_URL", defaults.BrokerURL),
+		ClientID:             getEnvString(prefix+"CLIENT_ID", defaults.ClientID),
+		Username:             getEnvString(prefix+"USERNAME", defaults.Username),
+		Password:             getEnvString(prefix+"PASSWORD", defaults.Password),
+		QoS:                  byte(getEnvInt(prefix+"QOS", int(defaults.QoS))),
+		KeepAlive:            getEnvInt(prefix+"KEEP_ALIVE", defaults.KeepAlive),
+		ConnectTimeout:       getEnvInt(prefix+"CONNECT_TIMEOUT", defaults.ConnectTimeout),
+		ReconnectDelay:       getEnvInt(prefix+"RECONNECT_DELAY", defaults.ReconnectDelay),
+		MaxReconnectDelay:    getEnvInt(prefix+"MAX_RECONNECT_DELAY", defaults.MaxReconnectDelay),
+		MaxReconnectAttempts: getEnvInt(prefix+"MAX_RECONNECT_ATTEMPTS", defaults.MaxReconnectAttempts),
+		CleanSession:         getEnvBool(prefix+"CLEAN_SESSION", defaults.CleanSession),
+		ProtocolVersion:      getEnvInt(prefix+"PROTOCOL_VERSION", defaults.ProtocolVersion),
+		CommandExpirySec:     getEnvInt(prefix+"COMMAND_EXPIRY_SEC", defaults.CommandExpirySec),
+		TLSCAFile:            getEnvString(prefix+"TLS_CA_FILE", defaults.TLSCAFile),
+		TLSCertFile:          getEnvString(prefix+"TLS_CERT_FILE", defaults.TLSCertFile),
+		TLSKeyFile:           getEnvString(prefix+"TLS_KEY_FILE", defaults.TLSKeyFile),
+		TLSServerName:        getEnvString(prefix+"TLS_SERVER_NAME", defaults.TLSServerName),
+	}
+}
+
+// loadMQTTConnections loads the named broker connections listed in MQTT_CONNECTIONS
+// Each connection reads MQTT_{NAME}_* and falls back to the MQTT_* value of the same setting
+// The PLC side defaults to the first connection, the robot side to all others (or the first if there is only one)
+func loadMQTTConnections(base MQTTConfig) ([]MQTTConfig, error) {
+	names := getEnvStringArray("MQTT_CONNECTIONS", nil)
+	if len(names) == 0 {
+		base.Name = "default"
+		base.ServesPLC = true
+		base.ServesRobots = true
+		return []MQTTConfig{base}, nil
+	}
+
+	defaultRobotConnections := names[1:]
+	if len(defaultRobotConnections) == 0 {
+		defaultRobotConnections = names
+	}
+	plcConnection := getEnvString("MQTT_PLC_CONNECTION", names[0])
+	robotConnections := getEnvStringArray("MQTT_ROBOT_CONNECTIONS", defaultRobotConnections)
+
+	connections := make([]MQTTConfig, 0, len(names))
+	for _, name := range names {
+		defaults := base
+		defaults.ClientID = base.ClientID + "_" + name
+		connection := loadMQTTConfig("MQTT_"+strings.ToUpper(name)+"_", defaults)
+		connection.Name = name
+		connection.ServesPLC = name == plcConnection
+		connection.ServesRobots = slices.Contains(robotConnections, name)
+		connections = append(connections, connection)
 	}
+
+	// Every role must point at a listed connection
+	if !slices.Contains(names, plcConnection) {
+		return nil, fmt.Errorf("MQTT_PLC_CONNECTION '%s' is not listed in MQTT_CONNECTIONS %v", plcConnection, names)
+	}
+	for _, name := range robotConnections {
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("MQTT_ROBOT_CONNECTIONS entry '%s' is not listed in MQTT_CONNECTIONS %v", name, names)
+		}
+	}
+	return connections, nil
+}
+
+// loadTopicConfig loads MQTT topic layout configuration
+func loadTopicConfig() TopicConfig {
+	return TopicConfig{
+		InterfaceName:         getEnvString("TOPIC_INTERFACE_NAME", "meili"),
+		MajorVersion:          getEnvString("TOPIC_MAJOR_VERSION", "v2"),
+		Manufacturer:          getEnvString("TOPIC_MANUFACTURER", "Roboligent"),
+		RobotTopicTemplate:    getEnvString("TOPIC_ROBOT_TEMPLATE", "{interfaceName}/{majorVersion}/{manufacturer}/{serialNumber}/{topic}"),
+		PLCActionTopic:        getEnvString("TOPIC_PLC_ACTIONS", "bridge/actions"),
+		PLCResultTopic:        getEnvString("TOPIC_PLC_ACTION_RESULTS", "bridge/actions/result"),
+		RobotEventTopic:       getEnvString("TOPIC_ROBOT_EVENTS", "bridge/events/{serial}"),
+		FleetStatusTopic:      getEnvString("TOPIC_FLEET_STATUS", "bridge/status"),
+		BridgeConnectionTopic: getEnvString("TOPIC_BRIDGE_CONNECTION", "bridge/connection"),
+		BridgeHeartbeatTopic:  getEnvString("TOPIC_BRIDGE_HEARTBEAT", "bridge/heartbeat"),
+	}
+}
+
+// isTargetRobot reports whether a robot is in the target list, comparing manufacturer/serial IDs
+// with bare serials under the default manufacturer; a bare target serial matches any manufacturer, as at runtime
+func isTargetRobot(robot string, targets []string, defaultManufacturer string) bool {
+	robotID := qualifyRobotID(robot, defaultManufacturer)
+	_, serial, _ := strings.Cut(robotID, "/")
+	for _, target := range targets {
+		if target == serial || qualifyRobotID(target, defaultManufacturer) == robotID {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifyRobotID returns "manufacturer/serial" for a target, using the default manufacturer for a bare serial
+func qualifyRobotID(target string, defaultManufacturer string) string {
+	if strings.Contains(target, "/") {
+		return target
+	}
+	return makeRobotID(defaultManufacturer, target)
+}
+
+// LoadCommandDefinitions loads the action catalog and the station registry and checks that every
+// station referenced by the catalog exists
+func LoadCommandDefinitions(config *AppConfig) (*ActionCatalog, *StationRegistry, error) {
+	catalog, err := LoadActionCatalog(config.ActionCatalogFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("APP_ACTION_CATALOG_FILE is invalid: %w", err)
+	}
+	stations, err := LoadStationRegistry(config.StationRegistryFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("APP_STATION_REGISTRY_FILE is invalid: %w", err)
+	}
+	if err := catalog.CheckStations(stations); err != nil {
+		return nil, nil, fmt.Errorf("APP_ACTION_CATALOG_FILE references unknown station: %w", err)
+	}
+	return catalog, stations, nil
 }
 
 // validateConfig validates the loaded configuration
@@ -99,30 +297,154 @@ func validateConfig(config *Config) error {
 	if config.App.StatusIntervalSeconds < 1 {
 		return fmt.Errorf("APP_STATUS_INTERVAL_SECONDS must be greater than 0")
 	}
+	if config.App.HeartbeatIntervalSec < 0 {
+		return fmt.Errorf("APP_HEARTBEAT_INTERVAL_SEC must be 0 (disabled) or greater")
+	}
+	if config.App.CommandQueueFile != "" && config.App.CommandQueueTTLSec < 1 {
+		return fmt.Errorf("APP_COMMAND_QUEUE_TTL_SEC must be greater than 0")
+	}
+	if !BusyPolicy(config.App.OrderBusyPolicy).IsValid() {
+		return fmt.Errorf("APP_ORDER_BUSY_POLICY must be one of reject, queue, preempt or send, got '%s'", config.App.OrderBusyPolicy)
+	}
+	if config.App.BusyQueueTTLSec < 1 {
+		return fmt.Errorf("APP_BUSY_QUEUE_TTL_SEC must be greater than 0")
+	}
+	if config.App.AutoInitMaxPoseAgeSec < 0 {
+		return fmt.Errorf("APP_AUTO_INIT_MAX_POSE_AGE_SEC must be 0 (no limit) or greater")
+	}
+	if config.App.DedupWindowSec < 0 {
+		return fmt.Errorf("APP_DEDUP_WINDOW_SEC must be 0 (disabled) or greater")
+	}
 	if len(config.App.TargetRobotSerials) == 0 {
 		return fmt.Errorf("APP_TARGET_ROBOT_SERIALS must contain at least one robot serial")
 	}
+	for _, target := range config.App.TargetRobotSerials {
+		if manufacturer, serial, qualified := strings.Cut(target, "/"); qualified &&
+			(manufacturer == "" || serial == "" || strings.Contains(serial, "/")) {
+			return fmt.Errorf("APP_TARGET_ROBOT_SERIALS entry '%s' must be 'serial' or 'manufacturer/serial'", target)
+		}
+	}
+
+	for name, members := range config.App.RobotGroups {
+		if strings.ContainsAny(name, broadcastTarget+groupTargetPrefix+requestIDSeparator+":/ ") {
+			return fmt.Errorf("APP_ROBOT_GROUPS name '%s' must not contain '*', '@', '#', ':', '/' or spaces", name)
+		}
+		if len(members) == 0 {
+			return fmt.Errorf("APP_ROBOT_GROUP_%s must list at least one robot of group '%s'", strings.ToUpper(name), name)
+		}
+		for _, member := range members {
+			if !isTargetRobot(member, config.App.TargetRobotSerials, config.Topic.Manufacturer) {
+				return fmt.Errorf("APP_ROBOT_GROUP_%s entry '%s' is not in APP_TARGET_ROBOT_SERIALS", strings.ToUpper(name), member)
+			}
+		}
+	}
+
+	// Validate MQTT connections
+	if len(config.Connections) == 0 {
+		return fmt.Errorf("at least one MQTT connection is required")
+	}
+	seenNames := make(map[string]bool)
+	seenClients := make(map[string]string)
+	for i := range config.Connections {
+		connection := &config.Connections[i]
+		if seenNames[connection.Name] {
+			return fmt.Errorf("MQTT_CONNECTIONS contains '%s' more than once", connection.Name)
+		}
+		seenNames[connection.Name] = true
+		if !connection.ServesPLC && !connection.ServesRobots {
+			return fmt.Errorf("MQTT connection '%s' serves neither the PLC nor the robots", connection.Name)
+		}
+		clientKey := connection.BrokerURL + " " + connection.ClientID
+		if other, exists := seenClients[clientKey]; exists {
+			return fmt.Errorf("MQTT connections '%s' and '%s' use the same client ID %s on %s",
+				other, connection.Name, connection.ClientID, connection.BrokerURL)
+		}
+		seenClients[clientKey] = connection.Name
+		if err := validateMQTTConfig(connection); err != nil {
+			return err
+		}
+	}
 
-	// Validate MQTT config
-	if config.MQTT.BrokerURL == "" {
-		return fmt.Errorf("MQTT_BROKER_URL is required")
+	// Validate Topic config
+	if config.Topic.Manufacturer == "" {
+		return fmt.Errorf("TOPIC_MANUFACTURER is required")
+	}
+	if config.Topic.PLCActionTopic == "" || config.Topic.PLCResultTopic == "" {
+		return fmt.Errorf("TOPIC_PLC_ACTIONS and TOPIC_PLC_ACTION_RESULTS are required")
 	}
-	if config.MQTT.ClientID == "" {
-		return fmt.Errorf("MQTT_CLIENT_ID is required")
+	if config.Topic.RobotEventTopic == "" {
+		return fmt.Errorf("TOPIC_ROBOT_EVENTS is required")
 	}
-	if config.MQTT.QoS > 2 {
-		return fmt.Errorf("MQTT_QOS must be 0, 1, or 2")
+	if config.Topic.FleetStatusTopic == "" {
+		return fmt.Errorf("TOPIC_FLEET_STATUS is required")
 	}
-	if config.MQTT.ConnectTimeout < 1 {
-		return fmt.Errorf("MQTT_CONNECT_TIMEOUT must be greater than 0")
+	if strings.ContainsAny(config.Topic.FleetStatusTopic, "+#") {
+		return fmt.Errorf("TOPIC_FLEET_STATUS must not contain wildcards: %s", config.Topic.FleetStatusTopic)
 	}
-	if config.MQTT.MaxReconnectAttempts < 1 {
-		return fmt.Errorf("MQTT_MAX_RECONNECT_ATTEMPTS must be greater than 0")
+	for name, topic := range map[string]string{
+		"TOPIC_BRIDGE_CONNECTION": config.Topic.BridgeConnectionTopic,
+		"TOPIC_BRIDGE_HEARTBEAT":  config.Topic.BridgeHeartbeatTopic,
+	} {
+		if topic == "" {
+			return fmt.Errorf("%s is required", name)
+		}
+		if strings.ContainsAny(topic, "+#") {
+			return fmt.Errorf("%s must not contain wildcards: %s", name, topic)
+		}
+	}
+	if _, err := NewTopicLayout(&config.Topic); err != nil {
+		return fmt.Errorf("TOPIC_ROBOT_TEMPLATE is invalid: %w", err)
 	}
 
 	return nil
 }
 
+// validateMQTTConfig validates the settings of one broker connection
+func validateMQTTConfig(mqttConfig *MQTTConfig) error {
+	prefix := mqttConfig.envPrefix
+	if mqttConfig.BrokerURL == "" {
+		return fmt.Errorf("%sBROKER_URL is required", prefix)
+	}
+	if mqttConfig.ClientID == "" {
+		return fmt.Errorf("%sCLIENT_ID is required", prefix)
+	}
+	if mqttConfig.QoS > 2 {
+		return fmt.Errorf("%sQOS must be 0, 1, or 2", prefix)
+	}
+	if mqttConfig.ConnectTimeout < 1 {
+		return fmt.Errorf("%sCONNECT_TIMEOUT must be greater than 0", prefix)
+	}
+	if mqttConfig.MaxReconnectAttempts < 1 {
+		return fmt.Errorf("%sMAX_RECONNECT_ATTEMPTS must be greater than 0", prefix)
+	}
+	if mqttConfig.ProtocolVersion != 4 && mqttConfig.ProtocolVersion != 5 {
+		return fmt.Errorf("%sPROTOCOL_VERSION must be 4 (MQTT 3.1.1) or 5 (MQTT 5)", prefix)
+	}
+	if mqttConfig.CommandExpirySec < 0 {
+		return fmt.Errorf("%sCOMMAND_EXPIRY_SEC must be 0 (no expiry) or greater", prefix)
+	}
+	if mqttConfig.ProtocolVersion == 5 {
+		if _, err := url.Parse(mqttConfig.BrokerURL); err != nil {
+			return fmt.Errorf("%sBROKER_URL is invalid: %w", prefix, err)
+		}
+		if mqttConfig.KeepAlive < 0 || mqttConfig.KeepAlive > math.MaxUint16 {
+			return fmt.Errorf("%sKEEP_ALIVE must be between 0 and %d", prefix, math.MaxUint16)
+		}
+	}
+	if (mqttConfig.TLSCertFile == "") != (mqttConfig.TLSKeyFile == "") {
+		return fmt.Errorf("%sTLS_CERT_FILE and %sTLS_KEY_FILE must be set together", prefix, prefix)
+	}
+	if mqttConfig.hasTLSSettings() && !isTLSBrokerURL(mqttConfig.BrokerURL) {
+		return fmt.Errorf("%sTLS_* settings require a TLS broker URL (ssl://, mqtts://, tls://, tcps://, wss://): %s", prefix, mqttConfig.BrokerURL)
+	}
+	if isTLSBrokerURL(mqttConfig.BrokerURL) {
+		if _, err := newTLSConfig(mqttConfig); err != nil {
+			return fmt.Errorf("%s TLS configuration is invalid: %w", strings.TrimSuffix(prefix, "_"), err)
+		}
+	}
+	return nil
+}
+
 // getEnvString gets environment variable as string with default value
 func getEnvString(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
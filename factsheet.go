@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FactsheetDiff describes how the advertised actions changed between two factsheets
+type FactsheetDiff struct {
+	AddedActions   []string `json:"addedActions,omitempty"`
+	RemovedActions []string `json:"removedActions,omitempty"`
+	ChangedActions []string `json:"changedActions,omitempty"`
+	VersionChanged bool     `json:"versionChanged"`
+}
+
+// IsEmpty reports whether the factsheets advertise the same capabilities
+func (fd FactsheetDiff) IsEmpty() bool {
+	return len(fd.AddedActions) == 0 && len(fd.RemovedActions) == 0 &&
+		len(fd.ChangedActions) == 0 && !fd.VersionChanged
+}
+
+// String formats the diff for logging
+func (fd FactsheetDiff) String() string {
+	return fmt.Sprintf("추가: %v, 제거: %v, 변경: %v, 버전 변경: %t",
+		fd.AddedActions, fd.RemovedActions, fd.ChangedActions, fd.VersionChanged)
+}
+
+// diffFactsheets compares the advertised actions of an old and a new factsheet
+func diffFactsheets(oldFactsheet, newFactsheet *FactsheetResponseMessage) FactsheetDiff {
+	oldActions := indexFactsheetActions(oldFactsheet)
+	newActions := indexFactsheetActions(newFactsheet)
+
+	diff := FactsheetDiff{
+		VersionChanged: oldFactsheet.Version != newFactsheet.Version,
+	}
+
+	for actionType, newAction := range newActions {
+		oldAction, exists := oldActions[actionType]
+		if !exists {
+			diff.AddedActions = append(diff.AddedActions, actionType)
+		} else if !reflect.DeepEqual(oldAction.ActionParameters, newAction.ActionParameters) ||
+			!reflect.DeepEqual(oldAction.ActionScopes, newAction.ActionScopes) {
+			diff.ChangedActions = append(diff.ChangedActions, actionType)
+		}
+	}
+	for actionType := range oldActions {
+		if _, exists := newActions[actionType]; !exists {
+			diff.RemovedActions = append(diff.RemovedActions, actionType)
+		}
+	}
+
+	sort.Strings(diff.AddedActions)
+	sort.Strings(diff.RemovedActions)
+	sort.Strings(diff.ChangedActions)
+	return diff
+}
+
+// indexFactsheetActions maps advertised actions by action type
+func indexFactsheetActions(factsheet *FactsheetResponseMessage) map[string]AGVAction {
+	actions := make(map[string]AGVAction, len(factsheet.ProtocolFeatures.AGVActions))
+	for _, action := range factsheet.ProtocolFeatures.AGVActions {
+		actions[action.ActionType] = action
+	}
+	return actions
+}
+
+// validateRobotCommandAgainstFactsheet checks every action of a robot command against the advertised capabilities
+func validateRobotCommandAgainstFactsheet(command *RobotCommand, factsheet *FactsheetResponseMessage) error {
+	advertised := indexFactsheetActions(factsheet)
+
+	if command.Kind == CommandInstantActions {
+		for _, action := range command.InstantActions.Actions {
+			if err := validateActionAgainstSpec(action, "INSTANT", advertised); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, node := range command.Order.Nodes {
+		for _, action := range node.Actions {
+			if err := validateActionAgainstSpec(action, "NODE", advertised); err != nil {
+				return fmt.Errorf("node %s: %w", node.NodeID, err)
+			}
+		}
+	}
+	for _, edge := range command.Order.Edges {
+		for _, action := range edge.Actions {
+			if err := validateActionAgainstSpec(action, "EDGE", advertised); err != nil {
+				return fmt.Errorf("edge %s: %w", edge.EdgeID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateActionAgainstSpec checks action type, scope and parameters against the factsheet specification
+func validateActionAgainstSpec(action Action, scope string, advertised map[string]AGVAction) error {
+	spec, exists := advertised[action.ActionType]
+	if !exists {
+		return fmt.Errorf("action type '%s' is not advertised in factsheet", action.ActionType)
+	}
+
+	// Check action scope (instant, node, edge)
+	if len(spec.ActionScopes) > 0 {
+		scopeAllowed := false
+		for _, allowedScope := range spec.ActionScopes {
+			if strings.EqualFold(allowedScope, scope) {
+				scopeAllowed = true
+				break
+			}
+		}
+		if !scopeAllowed {
+			return fmt.Errorf("action type '%s' does not support scope %s (allowed: %v)", action.ActionType, scope, spec.ActionScopes)
+		}
+	}
+
+	// Check parameter keys and value types
+	parameters := make(map[string]interface{}, len(action.ActionParameters))
+	for _, parameter := range action.ActionParameters {
+		parameters[parameter.Key] = parameter.Value
+	}
+
+	specified := make(map[string]bool, len(spec.ActionParameters))
+	for _, parameterSpec := range spec.ActionParameters {
+		specified[parameterSpec.Key] = true
+
+		value, present := parameters[parameterSpec.Key]
+		if !present {
+			if !parameterSpec.IsOptional {
+				return fmt.Errorf("action type '%s' requires parameter '%s'", action.ActionType, parameterSpec.Key)
+			}
+			continue
+		}
+		if !matchesValueDataType(value, parameterSpec.ValueDataType) {
+			return fmt.Errorf("action type '%s' parameter '%s' must be %s, got %T",
+				action.ActionType, parameterSpec.Key, parameterSpec.ValueDataType, value)
+		}
+	}
+
+	for key := range parameters {
+		if !specified[key] {
+			return fmt.Errorf("action type '%s' does not advertise parameter '%s'", action.ActionType, key)
+		}
+	}
+	return nil
+}
+
+// matchesValueDataType checks a parameter value against a VDA5050 value data type
+func matchesValueDataType(value interface{}, valueDataType string) bool {
+	kind := reflect.ValueOf(value).Kind()
+
+	switch strings.ToUpper(valueDataType) {
+	case "STRING":
+		return kind == reflect.String
+	case "BOOL", "BOOLEAN":
+		return kind == reflect.Bool
+	case "INTEGER":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Float32, reflect.Float64:
+			f := reflect.ValueOf(value).Float()
+			return f == float64(int64(f))
+		}
+		return false
+	case "NUMBER", "FLOAT":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	case "OBJECT":
+		return kind == reflect.Struct || kind == reflect.Map || kind == reflect.Ptr
+	case "ARRAY":
+		return kind == reflect.Slice || kind == reflect.Array
+	default:
+		// Unknown data types are not checked
+		return true
+	}
+}
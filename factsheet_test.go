@@ -0,0 +1,130 @@
+package main
+
+import "testing"
+
+// testFactsheet advertises an instant-only initPosition with a pose object and a pick action for nodes
+func testFactsheet() *FactsheetResponseMessage {
+	return &FactsheetResponseMessage{
+		ProtocolFeatures: ProtocolFeatures{
+			AGVActions: []AGVAction{
+				{ActionType: "initPosition", ActionScopes: []string{"INSTANT"}, ActionParameters: []ActionParameterSpec{
+					{Key: "pose", ValueDataType: "OBJECT"},
+				}},
+				{ActionType: "pick", ActionScopes: []string{"NODE"}, ActionParameters: []ActionParameterSpec{
+					{Key: "stationType", ValueDataType: "STRING"},
+					{Key: "height", ValueDataType: "FLOAT", IsOptional: true},
+				}},
+				{ActionType: "factsheetRequest"},
+			},
+		},
+	}
+}
+
+func TestValidateRobotCommandAgainstFactsheet(t *testing.T) {
+	instant := func(actions ...Action) *RobotCommand {
+		return &RobotCommand{Kind: CommandInstantActions, InstantActions: &InstantActionsMessage{Actions: actions}}
+	}
+	order := func(actions ...Action) *RobotCommand {
+		return &RobotCommand{Kind: CommandOrder, Order: &OrderMessage{
+			Nodes: []Node{{NodeID: "n1", Actions: actions}},
+		}}
+	}
+	pose := []ActionParameter{{Key: "pose", Value: map[string]interface{}{"x": 1.0}}}
+
+	tests := []struct {
+		name    string
+		command *RobotCommand
+		wantErr bool
+	}{
+		{"advertised instant action", instant(Action{ActionType: "initPosition", ActionParameters: pose}), false},
+		{"action without scopes or parameters", instant(Action{ActionType: "factsheetRequest"}), false},
+		{"advertised node action", order(Action{ActionType: "pick", ActionParameters: []ActionParameter{
+			{Key: "stationType", Value: "rack"}, {Key: "height", Value: 0.4},
+		}}), false},
+		{"optional parameter omitted", order(Action{ActionType: "pick", ActionParameters: []ActionParameter{
+			{Key: "stationType", Value: "rack"},
+		}}), false},
+
+		{"instant action not advertised", instant(Action{ActionType: "startPause"}), true},
+		{"node action not advertised", order(Action{ActionType: "drop"}), true},
+		{"instant action in node scope", order(Action{ActionType: "initPosition", ActionParameters: pose}), true},
+		{"node action as instant action", instant(Action{ActionType: "pick", ActionParameters: []ActionParameter{
+			{Key: "stationType", Value: "rack"},
+		}}), true},
+		{"required parameter missing", instant(Action{ActionType: "initPosition"}), true},
+		{"parameter of wrong type", order(Action{ActionType: "pick", ActionParameters: []ActionParameter{
+			{Key: "stationType", Value: 3},
+		}}), true},
+		{"parameter not advertised", instant(Action{ActionType: "initPosition", ActionParameters: append(pose,
+			ActionParameter{Key: "mapId", Value: "floor 0"})}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRobotCommandAgainstFactsheet(tt.command, testFactsheet())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateRobotCommandAgainstFactsheet error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchesValueDataType(t *testing.T) {
+	tests := []struct {
+		value         interface{}
+		valueDataType string
+		want          bool
+	}{
+		{"rack", "STRING", true},
+		{true, "BOOLEAN", true},
+		{3.0, "INTEGER", true},
+		{3.5, "INTEGER", false},
+		{3, "FLOAT", true},
+		{map[string]interface{}{}, "OBJECT", true},
+		{[]interface{}{}, "ARRAY", true},
+		{"3", "NUMBER", false},
+		{"anything", "CUSTOM", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchesValueDataType(tt.value, tt.valueDataType); got != tt.want {
+			t.Errorf("matchesValueDataType(%v, %s) = %t, want %t", tt.value, tt.valueDataType, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAgainstFactsheetStrictMode(t *testing.T) {
+	robotManager := NewRobotManager([]string{"DEX0001"}, nil, nil)
+	factsheet := testFactsheet()
+	factsheet.Manufacturer, factsheet.SerialNumber = "Roboligent", "DEX0001"
+	robotManager.UpdateFactsheet(factsheet)
+
+	notAdvertised := &RobotCommand{Kind: CommandInstantActions, InstantActions: &InstantActionsMessage{
+		Actions: []Action{{ActionType: "startPause"}},
+	}}
+
+	tests := []struct {
+		name    string
+		strict  bool
+		robotID string
+		action  string
+		wantErr bool
+	}{
+		{"strict rejects unadvertised action", true, "Roboligent/DEX0001", "stopPause", true},
+		{"lenient mode skips the check", false, "Roboligent/DEX0001", "stopPause", false},
+		{"factsheet request is always allowed", true, "Roboligent/DEX0001", "factsheetRequest", false},
+		{"no factsheet received yet", true, "Roboligent/DEX0002", "stopPause", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{App: AppConfig{StrictFactsheetCheck: tt.strict}}
+			mp := NewMessageProcessor(nil, nil, robotManager, nil, nil, nil, nil, config)
+
+			err := mp.validateAgainstFactsheet(notAdvertised, &PLCActionMessage{Action: tt.action}, tt.robotID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateAgainstFactsheet error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+)
+
+// fleetStatusMinInterval limits how often bursts of robot events republish the fleet status
+const fleetStatusMinInterval = 1 * time.Second
+
+// FleetStatus is the retained fleet summary for HMIs that subscribe late
+type FleetStatus struct {
+	Bridge         BridgeStatus        `json:"bridge"`
+	Robots         []RobotStatusDigest `json:"robots"`
+	MissingTargets []string            `json:"missingTargets,omitempty"`
+}
+
+// RobotStatusDigest is the compact per-robot part of the fleet status
+type RobotStatusDigest struct {
+	RobotID          string          `json:"robotId"`
+	SerialNumber     string          `json:"serialNumber"`
+	Manufacturer     string          `json:"manufacturer"`
+	ConnectionState  ConnectionState `json:"connectionState"`
+	IsOnline         bool            `json:"isOnline"`
+	IsTarget         bool            `json:"isTarget"`
+	CurrentOrderID   string          `json:"currentOrderId,omitempty"`
+	IsExecutingOrder bool            `json:"isExecutingOrder"`
+	IsDriving        bool            `json:"isDriving"`
+	IsPaused         bool            `json:"isPaused"`
+	OperatingMode    string          `json:"operatingMode,omitempty"`
+	BatteryLevel     float64         `json:"batteryLevel"`
+	IsCharging       bool            `json:"isCharging"`
+	LastNodeID       string          `json:"lastNodeId,omitempty"`
+	Position         *AGVPosition    `json:"position,omitempty"`
+	HasErrors        bool            `json:"hasErrors"`
+	LastError        *ErrorInfo      `json:"lastError,omitempty"`
+	HasSafetyIssue   bool            `json:"hasSafetyIssue"`
+	LastUpdate       time.Time       `json:"lastUpdate"`
+}
+
+// FleetStatusPublisher publishes the fleet status as a retained message on robot events, broker connection
+// changes and on the status interval
+type FleetStatusPublisher struct {
+	bridge  *MQTTBridge
+	topic   string
+	trigger chan struct{}
+}
+
+// NewFleetStatusPublisher creates a new fleet status publisher
+func NewFleetStatusPublisher(bridge *MQTTBridge, topic string) *FleetStatusPublisher {
+	return &FleetStatusPublisher{
+		bridge:  bridge,
+		topic:   topic,
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// HandleEvent requests a republish after a robot state change
+func (fsp *FleetStatusPublisher) HandleEvent(event RobotEvent) {
+	fsp.Trigger()
+}
+
+// HandleConnectionStatus requests a republish after a broker connection changed status
+func (fsp *FleetStatusPublisher) HandleConnectionStatus(name string, status ConnectionStatus) {
+	fsp.Trigger()
+}
+
+// Trigger requests a republish; requests arriving while one is pending are coalesced
+func (fsp *FleetStatusPublisher) Trigger() {
+	select {
+	case fsp.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes the fleet status whenever triggered until the context is cancelled
+func (fsp *FleetStatusPublisher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-fsp.trigger:
+			fsp.publish()
+		case <-ctx.Done():
+			return
+		}
+
+		// Let bursts of events collapse into a single publish
+		select {
+		case <-time.After(fleetStatusMinInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// GetFleetStatus builds the current fleet status
+func (fsp *FleetStatusPublisher) GetFleetStatus() FleetStatus {
+	robotManager := fsp.bridge.GetRobotManager()
+
+	robots := make([]RobotStatusDigest, 0)
+	for _, robot := range robotManager.GetAllRobots() {
+		robots = append(robots, RobotStatusDigest{
+			RobotID:          robot.RobotID,
+			SerialNumber:     robot.SerialNumber,
+			Manufacturer:     robot.Manufacturer,
+			ConnectionState:  robot.ConnectionState,
+			IsOnline:         robot.IsOnline,
+			IsTarget:         robotManager.IsTargetRobot(robot.Manufacturer, robot.SerialNumber),
+			CurrentOrderID:   robot.CurrentOrderID,
+			IsExecutingOrder: robot.IsExecutingOrder,
+			IsDriving:        robot.IsDriving,
+			IsPaused:         robot.IsPaused,
+			OperatingMode:    robot.OperatingMode,
+			BatteryLevel:     robot.BatteryLevel,
+			IsCharging:       robot.IsCharging,
+			LastNodeID:       robot.LastNodeID,
+			Position:         robot.CurrentPosition,
+			HasErrors:        robot.HasErrors,
+			LastError:        robot.LastError,
+			HasSafetyIssue:   robot.HasSafetyIssue,
+			LastUpdate:       robot.LastUpdate,
+		})
+	}
+	sort.Slice(robots, func(i, j int) bool {
+		return robots[i].RobotID < robots[j].RobotID
+	})
+
+	return FleetStatus{
+		Bridge:         fsp.bridge.GetBridgeStatus(),
+		Robots:         robots,
+		MissingTargets: robotManager.GetMissingTargetRobots(),
+	}
+}
+
+// publish sends the current fleet status as a retained message
+func (fsp *FleetStatusPublisher) publish() {
+	payload, err := json.Marshal(fsp.GetFleetStatus())
+	if err != nil {
+		log.Printf("❌ 전체 상태 JSON 변환 실패: %v", err)
+		return
+	}
+
+	if err := fsp.bridge.GetMQTTClient().PublishRetained(fsp.topic, payload); err != nil {
+		log.Printf("❌ 전체 상태 발행 실패 - Topic: %s, Error: %v", fsp.topic, err)
+	}
+}
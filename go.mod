@@ -3,6 +3,7 @@ module mqtt-bridge
 go 1.24.3
 
 require (
+	github.com/eclipse/paho.golang v0.22.0
 	github.com/eclipse/paho.mqtt.golang v1.5.0
 	github.com/joho/godotenv v1.5.1
 )
@@ -0,0 +1,83 @@
+package main
+
+import "time"
+
+// effectConfirmTimeout is how long the robot state may lag behind a FINISHED instant action
+const effectConfirmTimeout = 30 * time.Second
+
+// instantActionEffect describes the robot state a predefined instant action must produce
+type instantActionEffect struct {
+	actionType  string
+	description string
+	confirmed   func(stateMsg *RobotStateMessage) bool
+}
+
+// instantActionEffects maps VDA5050 predefined instant actions to their observable effect
+// Actions without an entry are confirmed by their action state alone
+var instantActionEffects = map[string]instantActionEffect{
+	"startPause": {
+		actionType:  "startPause",
+		description: "paused",
+		confirmed:   func(stateMsg *RobotStateMessage) bool { return stateMsg.Paused },
+	},
+	"stopPause": {
+		actionType:  "stopPause",
+		description: "not paused",
+		confirmed:   func(stateMsg *RobotStateMessage) bool { return !stateMsg.Paused },
+	},
+	"startCharging": {
+		actionType:  "startCharging",
+		description: "charging",
+		confirmed:   func(stateMsg *RobotStateMessage) bool { return stateMsg.BatteryState.Charging },
+	},
+	"stopCharging": {
+		actionType:  "stopCharging",
+		description: "not charging",
+		confirmed:   func(stateMsg *RobotStateMessage) bool { return !stateMsg.BatteryState.Charging },
+	},
+	"detectObject": {
+		actionType:  "detectObject",
+		description: "reporting the detected objects",
+		confirmed: func(stateMsg *RobotStateMessage) bool {
+			for _, actionState := range stateMsg.ActionStates {
+				if actionState.ActionType == "detectObject" && actionState.ActionStatus == "FINISHED" &&
+					actionState.ResultDescription != "" {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	"cancelOrder": {
+		actionType:  "cancelOrder",
+		description: "no remaining nodes and edges",
+		confirmed: func(stateMsg *RobotStateMessage) bool {
+			return len(stateMsg.NodeStates) == 0 && len(stateMsg.EdgeStates) == 0
+		},
+	},
+}
+
+// instantActionEffectsOf returns the effects the instant actions of a command must produce
+func instantActionEffectsOf(command *RobotCommand) []instantActionEffect {
+	if command.Kind != CommandInstantActions {
+		return nil
+	}
+
+	var effects []instantActionEffect
+	for _, action := range command.InstantActions.Actions {
+		if effect, exists := instantActionEffects[action.ActionType]; exists {
+			effects = append(effects, effect)
+		}
+	}
+	return effects
+}
+
+// unconfirmedEffect returns the first effect not yet visible in the robot state
+func unconfirmedEffect(effects []instantActionEffect, stateMsg *RobotStateMessage) (instantActionEffect, bool) {
+	for _, effect := range effects {
+		if !effect.confirmed(stateMsg) {
+			return effect, true
+		}
+	}
+	return instantActionEffect{}, false
+}
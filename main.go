@@ -8,7 +8,7 @@ import (
 )
 
 func main() {
-	log.Printf("🚀 MQTT Robot Bridge 시작...")
+	log.Printf("🚀 MQTT Robot Bridge 시작... (Version: %s)", BridgeVersion)
 
 	// Load configuration
 	config, err := LoadConfig()
@@ -18,16 +18,58 @@ func main() {
 
 	log.Printf("📋 설정 로드 완료")
 	log.Printf("   - Environment: %s", config.App.Environment)
-	log.Printf("   - MQTT Broker: %s", config.MQTT.BrokerURL)
-	log.Printf("   - MQTT Client ID: %s", config.MQTT.ClientID)
+	for _, connection := range config.Connections {
+		log.Printf("   - MQTT Connection [%s]: %s (Client ID: %s, PLC: %t, Robots: %t)",
+			connection.Name, connection.BrokerURL, connection.ClientID, connection.ServesPLC, connection.ServesRobots)
+		if connection.ProtocolVersion == 5 {
+			log.Printf("     Protocol: 5 (Command Expiry: %ds)", connection.CommandExpirySec)
+		} else {
+			log.Printf("     Protocol: 3.1.1")
+		}
+		if isTLSBrokerURL(connection.BrokerURL) {
+			log.Printf("     TLS: CA=%s, Client Cert=%s, Server Name=%s",
+				valueOrDefault(connection.TLSCAFile, "system"),
+				valueOrDefault(connection.TLSCertFile, "none"),
+				valueOrDefault(connection.TLSServerName, "broker host"))
+		}
+	}
 	log.Printf("   - Target Robots: %v", config.App.TargetRobotSerials)
-	log.Printf("   - Auto Init on Connect: %t (Delay: %ds)", config.App.AutoInitOnConnect, config.App.AutoInitDelaySec)
+	for name, members := range config.App.RobotGroups {
+		log.Printf("   - Robot Group @%s: %v", name, members)
+	}
+	log.Printf("   - Auto Init on Connect: %t (Delay: %ds, Last Pose: %t, Max Pose Age: %ds)",
+		config.App.AutoInitOnConnect, config.App.AutoInitDelaySec, config.App.AutoInitUseLastPose, config.App.AutoInitMaxPoseAgeSec)
+	log.Printf("   - Strict Factsheet Check: %t", config.App.StrictFactsheetCheck)
+	if config.App.ActionCatalogFile != "" {
+		log.Printf("   - Action Catalog: %s", config.App.ActionCatalogFile)
+	}
+	if config.App.StationRegistryFile != "" {
+		log.Printf("   - Station Registry: %s", config.App.StationRegistryFile)
+	}
+	if config.App.CommandQueueFile != "" {
+		log.Printf("   - Command Queue: %s (TTL: %ds)", config.App.CommandQueueFile, config.App.CommandQueueTTLSec)
+	}
+	log.Printf("   - Order Busy Policy: %s (Wait TTL: %ds)", config.App.OrderBusyPolicy, config.App.BusyQueueTTLSec)
+	if config.App.DedupWindowSec > 0 {
+		log.Printf("   - Request ID Dedup Window: %ds", config.App.DedupWindowSec)
+	}
 	log.Printf("   - Log Level: %s", config.App.LogLevel)
 	log.Printf("   - Status Interval: %ds", config.App.StatusIntervalSeconds)
-	log.Printf("   - Max Reconnect Attempts: %d", config.MQTT.MaxReconnectAttempts)
+	if config.App.HTTPListenAddr != "" {
+		log.Printf("   - HTTP API: %s", config.App.HTTPListenAddr)
+	}
+
+	// Load PLC command definitions
+	catalog, stations, err := LoadCommandDefinitions(&config.App)
+	if err != nil {
+		log.Fatalf("❌ 명령 정의 로드 실패: %v", err)
+	}
 
 	// Create and start MQTT bridge
-	bridge := NewMQTTBridge(config)
+	bridge, err := NewMQTTBridge(config, catalog, stations)
+	if err != nil {
+		log.Fatalf("❌ 브릿지 생성 실패: %v", err)
+	}
 
 	// Setup graceful shutdown
 	signalChan := make(chan os.Signal, 1)
@@ -60,13 +102,21 @@ func main() {
 
 	log.Printf("🎯 MQTT 브릿지가 작동 중입니다...")
 	log.Printf("   📥 구독 토픽:")
-	log.Printf("      - PLC Actions: bridge/actions")
-	log.Printf("      - Robot Connection: meili/v2/Roboligent/+/connection")
-	log.Printf("      - Robot State: meili/v2/Roboligent/+/state")
-	log.Printf("      - Robot Factsheet: meili/v2/+/+/factsheet")
+	topics := bridge.GetTopicLayout()
+	log.Printf("      - PLC Actions: %s", topics.PLCActionTopic())
+	log.Printf("      - Robot Connection: %s", topics.RobotSubscription("+", topicConnection))
+	log.Printf("      - Robot State: %s", topics.RobotSubscription("+", topicState))
+	log.Printf("      - Robot Factsheet: %s", topics.RobotSubscription("+", topicFactsheet))
 	log.Printf("   📤 발행 토픽:")
-	log.Printf("      - Robot Actions: meili/v2/Roboligent/{serial}/instantActions")
-	log.Printf("      - Robot Orders: meili/v2/Roboligent/{serial}/orders")
+	log.Printf("      - Robot Actions: %s", topics.RobotInstantActionsTopic("{manufacturer}", "{serial}"))
+	log.Printf("      - Robot Orders: %s", topics.RobotOrderTopic("{manufacturer}", "{serial}"))
+	log.Printf("      - PLC Action Results: %s", topics.PLCResultTopic("{serial}"))
+	log.Printf("      - Robot Events: %s", topics.RobotEventTopic("{manufacturer}", "{serial}"))
+	log.Printf("      - Fleet Status (retained): %s", topics.FleetStatusTopic())
+	log.Printf("      - Bridge Connection (retained, LWT): %s", topics.BridgeConnectionTopic())
+	if config.App.HeartbeatIntervalSec > 0 {
+		log.Printf("      - Bridge Heartbeat (%ds): %s", config.App.HeartbeatIntervalSec, topics.BridgeHeartbeatTopic())
+	}
 	log.Printf("   💡 종료하려면 Ctrl+C를 누르세요")
 
 	// Wait for shutdown signal (모든 모니터링은 bridge 내부에서 처리)
@@ -92,3 +142,11 @@ func main() {
 
 	log.Printf("👋 MQTT Robot Bridge 종료됨")
 }
+
+// valueOrDefault returns value, or fallback when value is empty
+func valueOrDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -1,31 +1,90 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-
-	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
+// commandQueueCheckInterval is how often queued commands are checked for delivery and expiry
+const commandQueueCheckInterval = 1 * time.Second
+
 // MessageProcessor handles all MQTT message processing
 type MessageProcessor struct {
-	mqttClient    *MQTTClient
-	robotManager  *RobotManager
-	actionHandler *ActionHandler
-	config        *Config
+	connections    *BrokerConnections
+	topics         *TopicLayout
+	robotManager   *RobotManager
+	actionHandler  *ActionHandler
+	orderTracker   *OrderTracker
+	resultReporter *ActionResultReporter
+	dispatcher     *RobotDispatcher
+	commandQueue   *CommandQueue        // nil if the outbound queue is disabled
+	deduplicator   *RequestDeduplicator // nil if request id deduplication is disabled
+	config         *Config
+
+	dispatchMutex sync.Mutex // serializes dispatch decisions per bridge
+}
+
+// DispatchOutcome is the result of submitting a command for a robot
+type DispatchOutcome struct {
+	Command *RobotCommand   // published command, nil if the command waits
+	Waiting *WaitingCommand // command waiting for the robot to finish its current order
+}
+
+// Error classes for commands that are not accepted, told apart with errors.Is
+var (
+	ErrInvalidCommand = errors.New("invalid command")
+	ErrUnknownTarget  = errors.New("unknown target")
+)
+
+// errRobotsDisconnected rejects commands while no robot side broker is connected and the outbound queue is disabled
+var errRobotsDisconnected = errors.New("robot side MQTT broker is not connected")
+
+// classifiedError tags an error with its class without changing the message reported to the requester
+type classifiedError struct {
+	class error
+	err   error
 }
 
+// classifyError tags err with class
+func classifyError(class error, err error) error {
+	return &classifiedError{class: class, err: err}
+}
+
+func (ce *classifiedError) Error() string        { return ce.err.Error() }
+func (ce *classifiedError) Unwrap() error        { return ce.err }
+func (ce *classifiedError) Is(target error) bool { return target == ce.class }
+
 // NewMessageProcessor creates a new message processor
-func NewMessageProcessor(mqttClient *MQTTClient, robotManager *RobotManager, actionHandler *ActionHandler, config *Config) *MessageProcessor {
+func NewMessageProcessor(connections *BrokerConnections, topics *TopicLayout, robotManager *RobotManager, actionHandler *ActionHandler, orderTracker *OrderTracker, resultReporter *ActionResultReporter, dispatcher *RobotDispatcher, config *Config) *MessageProcessor {
 	return &MessageProcessor{
-		mqttClient:    mqttClient,
-		robotManager:  robotManager,
-		actionHandler: actionHandler,
-		config:        config,
+		connections:    connections,
+		topics:         topics,
+		robotManager:   robotManager,
+		actionHandler:  actionHandler,
+		orderTracker:   orderTracker,
+		resultReporter: resultReporter,
+		dispatcher:     dispatcher,
+		config:         config,
 	}
 }
 
+// SetCommandQueue enables queueing PLC commands while the robot side broker is unreachable
+func (mp *MessageProcessor) SetCommandQueue(commandQueue *CommandQueue) {
+	mp.commandQueue = commandQueue
+}
+
+// SetRequestDeduplicator enables answering repeated PLC request ids with the original result
+func (mp *MessageProcessor) SetRequestDeduplicator(deduplicator *RequestDeduplicator) {
+	mp.deduplicator = deduplicator
+}
+
 // GetMessageHandlers returns handlers for all message types
 func (mp *MessageProcessor) GetMessageHandlers() *MessageHandlers {
 	return &MessageHandlers{
@@ -37,11 +96,11 @@ func (mp *MessageProcessor) GetMessageHandlers() *MessageHandlers {
 }
 
 // handleRobotConnectionMessage processes basic robot connection status messages
-func (mp *MessageProcessor) handleRobotConnectionMessage(client mqtt.Client, msg mqtt.Message) {
-	log.Printf("📨 로봇 연결 상태 메시지 수신 - Topic: %s", msg.Topic())
+func (mp *MessageProcessor) handleRobotConnectionMessage(msg *Message) {
+	log.Printf("📨 로봇 연결 상태 메시지 수신 - Topic: %s", msg.Topic)
 
-	// Parse topic to get serial number
-	serialNumber, err := parseRobotConnectionTopic(msg.Topic())
+	// Parse topic to get serial number and manufacturer
+	serialNumber, manufacturer, err := mp.topics.ParseRobotTopic(msg.Topic, topicConnection)
 	if err != nil {
 		log.Printf("❌ 연결 토픽 파싱 실패: %v", err)
 		return
@@ -49,27 +108,30 @@ func (mp *MessageProcessor) handleRobotConnectionMessage(client mqtt.Client, msg
 
 	// Parse as basic connection message
 	var connectionMsg RobotConnectionMessage
-	if err := json.Unmarshal(msg.Payload(), &connectionMsg); err != nil {
+	if err := json.Unmarshal(msg.Payload, &connectionMsg); err != nil {
 		log.Printf("❌ 연결 메시지 JSON 파싱 실패: %v", err)
 		return
 	}
 
 	// Validate and update robot status
-	if err := mp.validateAndUpdateRobotConnectionStatus(&connectionMsg, serialNumber); err != nil {
+	if err := mp.validateAndUpdateRobotConnectionStatus(&connectionMsg, serialNumber, manufacturer); err != nil {
 		log.Printf("❌ 로봇 연결 상태 업데이트 실패: %v", err)
 		return
 	}
 
+	// Send later commands over the broker this robot reports on
+	mp.connections.NoteRobot(makeRobotID(connectionMsg.Manufacturer, serialNumber), msg.Connection)
+
 	log.Printf("✅ 로봇 연결 상태 업데이트 완료 - Serial: %s, State: %s, HeaderID: %d",
 		connectionMsg.SerialNumber, connectionMsg.ConnectionState, connectionMsg.HeaderID)
 }
 
 // handleRobotStateMessage processes detailed robot state messages
-func (mp *MessageProcessor) handleRobotStateMessage(client mqtt.Client, msg mqtt.Message) {
-	log.Printf("📊 로봇 상태 메시지 수신 - Topic: %s", msg.Topic())
+func (mp *MessageProcessor) handleRobotStateMessage(msg *Message) {
+	log.Printf("📊 로봇 상태 메시지 수신 - Topic: %s", msg.Topic)
 
-	// Parse topic to get serial number
-	serialNumber, err := parseRobotStateTopic(msg.Topic())
+	// Parse topic to get serial number and manufacturer
+	serialNumber, manufacturer, err := mp.topics.ParseRobotTopic(msg.Topic, topicState)
 	if err != nil {
 		log.Printf("❌ 상태 토픽 파싱 실패: %v", err)
 		return
@@ -77,36 +139,39 @@ func (mp *MessageProcessor) handleRobotStateMessage(client mqtt.Client, msg mqtt
 
 	// Parse as detailed state message
 	var stateMsg RobotStateMessage
-	if err := json.Unmarshal(msg.Payload(), &stateMsg); err != nil {
+	if err := json.Unmarshal(msg.Payload, &stateMsg); err != nil {
 		log.Printf("❌ 상태 메시지 JSON 파싱 실패: %v", err)
 		return
 	}
 
 	// Validate and update robot detailed status
-	if err := mp.validateAndUpdateRobotStateStatus(&stateMsg, serialNumber); err != nil {
+	if err := mp.validateAndUpdateRobotStateStatus(&stateMsg, serialNumber, manufacturer); err != nil {
 		log.Printf("❌ 로봇 상태 업데이트 실패: %v", err)
 		return
 	}
 
+	// Send later commands over the broker this robot reports on
+	mp.connections.NoteRobot(makeRobotID(stateMsg.Manufacturer, serialNumber), msg.Connection)
+
 	// Log essential status info
 	log.Printf("📊 로봇 상태 업데이트 완료 - Serial: %s, 배터리: %.1f%%, 주행: %t, 주문: %s",
 		stateMsg.SerialNumber, stateMsg.BatteryState.BatteryCharge, stateMsg.Driving, stateMsg.OrderID)
 }
 
 // validateAndUpdateRobotConnectionStatus validates and updates basic robot connection status
-func (mp *MessageProcessor) validateAndUpdateRobotConnectionStatus(msg *RobotConnectionMessage, serialNumber string) error {
+func (mp *MessageProcessor) validateAndUpdateRobotConnectionStatus(msg *RobotConnectionMessage, serialNumber string, manufacturer string) error {
 	// Validate message
 	if msg.SerialNumber == "" || msg.Manufacturer == "" || msg.Version == "" {
 		return fmt.Errorf("missing required fields in connection message")
 	}
 
-	// Validate serial number consistency
-	if msg.SerialNumber != serialNumber {
-		return fmt.Errorf("serial number mismatch - Topic: %s, Message: %s", serialNumber, msg.SerialNumber)
+	// Validate serial number and manufacturer consistency
+	if err := mp.validateTopicIdentity(serialNumber, manufacturer, msg.SerialNumber, msg.Manufacturer); err != nil {
+		return err
 	}
 
 	// Check if this robot is in target list
-	if !mp.robotManager.IsTargetRobot(serialNumber) {
+	if !mp.robotManager.IsTargetRobot(msg.Manufacturer, serialNumber) {
 		return nil // Silently ignore non-target robots
 	}
 
@@ -116,46 +181,66 @@ func (mp *MessageProcessor) validateAndUpdateRobotConnectionStatus(msg *RobotCon
 }
 
 // validateAndUpdateRobotStateStatus validates and updates detailed robot state status
-func (mp *MessageProcessor) validateAndUpdateRobotStateStatus(msg *RobotStateMessage, serialNumber string) error {
+func (mp *MessageProcessor) validateAndUpdateRobotStateStatus(msg *RobotStateMessage, serialNumber string, manufacturer string) error {
 	// Validate message
 	if msg.SerialNumber == "" || msg.Manufacturer == "" || msg.Version == "" {
 		return fmt.Errorf("missing required fields in state message")
 	}
 
-	// Validate serial number consistency
-	if msg.SerialNumber != serialNumber {
-		return fmt.Errorf("serial number mismatch - Topic: %s, Message: %s", serialNumber, msg.SerialNumber)
+	// Validate serial number and manufacturer consistency
+	if err := mp.validateTopicIdentity(serialNumber, manufacturer, msg.SerialNumber, msg.Manufacturer); err != nil {
+		return err
 	}
 
 	// Check if this robot is in target list
-	if !mp.robotManager.IsTargetRobot(serialNumber) {
+	if !mp.robotManager.IsTargetRobot(msg.Manufacturer, serialNumber) {
 		return nil // Silently ignore non-target robots
 	}
 
 	// Update robot detailed status
 	mp.robotManager.UpdateRobotStateStatus(msg)
+
+	// Follow issued orders and correlate published commands with the reported states
+	mp.orderTracker.HandleRobotState(msg)
+	mp.resultReporter.HandleRobotState(msg)
+
+	// Send commands that waited for the robot to finish its order
+	robotID := makeRobotID(msg.Manufacturer, serialNumber)
+	mp.dispatcher.NoteRobotState(robotID, msg.OrderID)
+	mp.releaseWaitingCommands(robotID)
+	return nil
+}
+
+// validateTopicIdentity checks that the message was published on the topic of the robot it describes
+func (mp *MessageProcessor) validateTopicIdentity(topicSerial, topicManufacturer, msgSerial, msgManufacturer string) error {
+	if msgSerial != topicSerial {
+		return fmt.Errorf("serial number mismatch - Topic: %s, Message: %s", topicSerial, msgSerial)
+	}
+	if mp.topics.HasManufacturerSegment() && msgManufacturer != topicManufacturer {
+		return fmt.Errorf("manufacturer mismatch - Topic: %s, Message: %s", topicManufacturer, msgManufacturer)
+	}
 	return nil
 }
 
 // handleRobotFactsheetMessage processes robot factsheet response messages
-func (mp *MessageProcessor) handleRobotFactsheetMessage(client mqtt.Client, msg mqtt.Message) {
-	log.Printf("📋 로봇 Factsheet 응답 수신 - Topic: %s", msg.Topic())
+func (mp *MessageProcessor) handleRobotFactsheetMessage(msg *Message) {
+	log.Printf("📋 로봇 Factsheet 응답 수신 - Topic: %s", msg.Topic)
 
-	// Parse topic to get serial number
-	serialNumber, _, err := parseRobotFactsheetTopic(msg.Topic())
+	// Parse topic to get serial number and manufacturer
+	serialNumber, manufacturer, err := mp.topics.ParseRobotTopic(msg.Topic, topicFactsheet)
 	if err != nil {
 		log.Printf("❌ Factsheet 토픽 파싱 실패: %v", err)
 		return
 	}
 
 	// Check if this robot is in target list
-	if !mp.robotManager.IsTargetRobot(serialNumber) {
+	if !mp.robotManager.IsTargetRobot(manufacturer, serialNumber) {
 		return // Silently ignore non-target robots
 	}
 
 	// Parse factsheet response
 	var factsheetMsg FactsheetResponseMessage
-	if err := json.Unmarshal(msg.Payload(), &factsheetMsg); err != nil {
+	if err := json.Unmarshal(msg.Payload, &factsheetMsg); err != nil {
 		log.Printf("❌ Factsheet 응답 파싱 실패: %v", err)
 		return
 	}
@@ -166,125 +251,624 @@ func (mp *MessageProcessor) handleRobotFactsheetMessage(client mqtt.Client, msg
 		return
 	}
 
-	// Validate serial number consistency
-	if factsheetMsg.SerialNumber != serialNumber {
-		log.Printf("❌ Factsheet 시리얼 번호 불일치 - Topic: %s, Message: %s", serialNumber, factsheetMsg.SerialNumber)
+	// Validate serial number and manufacturer consistency
+	if factsheetMsg.Manufacturer == "" {
+		factsheetMsg.Manufacturer = manufacturer
+	}
+	if err := mp.validateTopicIdentity(serialNumber, manufacturer, factsheetMsg.SerialNumber, factsheetMsg.Manufacturer); err != nil {
+		log.Printf("❌ Factsheet 식별 정보 불일치: %v", err)
 		return
 	}
 
-	// Update robot factsheet status
-	mp.robotManager.UpdateFactsheetReceived(serialNumber)
+	// Store factsheet and update robot factsheet status
+	mp.robotManager.UpdateFactsheet(&factsheetMsg)
 
 	// Log factsheet details
 	log.Printf("📋 Factsheet 수신 완료 - Serial: %s, Manufacturer: %s, Actions: %d개",
 		serialNumber, factsheetMsg.Manufacturer, len(factsheetMsg.ProtocolFeatures.AGVActions))
 }
 
-// handlePLCActionMessage processes PLC action messages from bridge/actions topic
-func (mp *MessageProcessor) handlePLCActionMessage(client mqtt.Client, msg mqtt.Message) {
-	log.Printf("📨 PLC 액션 메시지 수신 - Payload: %s", string(msg.Payload()))
+// handlePLCActionMessage processes PLC action messages from the PLC action topic
+func (mp *MessageProcessor) handlePLCActionMessage(msg *Message) {
+	log.Printf("📨 PLC 액션 메시지 수신 - Payload: %s", string(msg.Payload))
 
-	// Check MQTT connection
-	if !mp.mqttClient.IsConnected() {
-		log.Printf("❌ MQTT 클라이언트가 연결되지 않아 액션을 전송할 수 없습니다")
-		return
-	}
+	// MQTT 5 requests with a response topic are also answered directly
+	reply := newResponseTarget(msg)
 
 	// Parse and validate PLC action
-	plcAction, err := ParsePLCActionMessage(msg.Payload())
+	plcAction, err := ParsePLCActionMessage(msg.Payload)
 	if err != nil {
 		log.Printf("❌ PLC 액션 메시지 파싱 실패: %v", err)
+		mp.resultReporter.ReportRejected(&PLCActionMessage{Action: string(msg.Payload), Reply: reply}, err)
 		return
 	}
+	plcAction.Reply = reply
+
+	mp.SubmitPLCAction(plcAction)
+}
+
+// SubmitPLCAction validates, accepts and dispatches a parsed command, reporting every stage to the requester
+// It returns the last result reported before it returns, and the error of a rejected or failed command
+func (mp *MessageProcessor) SubmitPLCAction(plcAction *PLCActionMessage) (PLCActionResult, error) {
+	// Fold the parameters and station of JSON messages into the command grammar
+	if err := mp.actionHandler.NormalizePLCAction(plcAction); err != nil {
+		log.Printf("❌ PLC 액션 변환 실패: %v", err)
+		err = classifyError(ErrInvalidCommand, err)
+		return mp.resultReporter.ReportRejected(plcAction, err), err
+	}
 
-	if err := ValidatePLCAction(plcAction); err != nil {
+	if err := mp.actionHandler.ValidatePLCAction(plcAction); err != nil {
 		log.Printf("❌ PLC 액션 검증 실패: %v", err)
-		return
+		err = classifyError(ErrInvalidCommand, err)
+		return mp.resultReporter.ReportRejected(plcAction, err), err
+	}
+	if _, _, err := mp.robotManager.ExpandTarget(plcAction.SerialNumber); err != nil {
+		log.Printf("❌ PLC 액션 대상 확인 실패: %v", err)
+		return mp.resultReporter.ReportRejected(plcAction, err), err
 	}
 
+	// Check robot side MQTT connection (with the outbound queue, commands are queued instead)
+	// before claiming the request id, so that the PLC can retry the same request once the broker is back
+	if mp.commandQueue == nil && !mp.connections.RobotsConnected() {
+		log.Printf("❌ MQTT 클라이언트가 연결되지 않아 액션을 전송할 수 없습니다")
+		return mp.resultReporter.ReportRejected(plcAction, errRobotsDisconnected), errRobotsDisconnected
+	}
+
+	// Answer redeliveries of a request id with the original result instead of dispatching again
+	// (after validation, so malformed commands do not take up request ids)
+	if mp.deduplicator != nil && plcAction.RequestID != "" {
+		original, duplicate, err := mp.deduplicator.Claim(plcAction)
+		if err != nil {
+			log.Printf("❌ PLC 요청 ID 재사용: %v", err)
+			err = classifyError(ErrInvalidCommand, err)
+			return mp.resultReporter.ReportRejected(plcAction, err), err
+		}
+		if duplicate {
+			log.Printf("♻️  중복 PLC 요청 - 재전송 생략 - RequestID: %s, Serial: %s, Action: %s, 원본 상태: %s",
+				plcAction.RequestID, plcAction.SerialNumber, plcAction.Action, original.Status)
+			return mp.resultReporter.ReportDuplicate(plcAction, *original), nil
+		}
+	}
+
+	mp.resultReporter.ReportAccepted(plcAction)
+
+	// Hold the command while the robot's broker is unreachable or earlier commands are still queued
+	if mp.shouldQueue(plcAction) {
+		queued, err := mp.commandQueue.Enqueue(plcAction)
+		if err != nil {
+			log.Printf("❌ 명령 큐 저장 실패 - Serial: %s, Error: %v", plcAction.SerialNumber, err)
+			err = fmt.Errorf("failed to queue command: %w", err)
+			return mp.resultReporter.ReportFailed(plcAction, err), err
+		}
+		log.Printf("📦 PLC 액션 큐 저장 - Action: %s, Target: %s, 대기: %d개", plcAction.Action, plcAction.SerialNumber, mp.commandQueue.Len())
+		return mp.resultReporter.ReportQueued(plcAction, fmt.Sprintf("robot side broker unavailable, queued until %s",
+			queued.ExpiresAt.UTC().Format(time.RFC3339))), nil
+	}
+
+	return mp.dispatchPLCAction(plcAction)
+}
+
+// dispatchPLCAction sends an accepted PLC action to its robot and reports the outcome
+func (mp *MessageProcessor) dispatchPLCAction(plcAction *PLCActionMessage) (PLCActionResult, error) {
 	log.Printf("🚀 PLC 액션 처리 시작 - Action: %s, Target: %s", plcAction.Action, plcAction.SerialNumber)
 
-	// Send action to target robot
-	if err := mp.sendActionToRobot(plcAction, plcAction.SerialNumber); err != nil {
+	// Group ("@cellA") and fleet ("*") commands go to every addressed robot
+	robotTargets, fanOut, err := mp.robotManager.ExpandTarget(plcAction.SerialNumber)
+	if err != nil {
+		log.Printf("❌ PLC 액션 대상 확인 실패: %v", err)
+		return mp.resultReporter.ReportFailed(plcAction, err), err
+	}
+	if fanOut {
+		return mp.broadcastPLCAction(plcAction, robotTargets), nil
+	}
+
+	// Send action to target robot, or hold it back while the robot executes an order
+	outcome, err := mp.submitAction(plcAction, plcAction.SerialNumber)
+	switch {
+	case errors.Is(err, ErrRobotBusy):
+		log.Printf("⛔ 로봇 작업 중 - 액션 거부 - Serial: %s, Error: %v", plcAction.SerialNumber, err)
+		result := mp.resultReporter.ReportRejected(plcAction, err)
+		// The rejection is transient, a retry with the same request id must be dispatched again
+		if mp.deduplicator != nil {
+			mp.deduplicator.Release(plcAction)
+		}
+		return result, err
+	case err != nil:
 		log.Printf("❌ 로봇에 액션 전송 실패 - Serial: %s, Error: %v", plcAction.SerialNumber, err)
-		return
+		return mp.resultReporter.ReportFailed(plcAction, err), err
+	case outcome.Waiting != nil:
+		return mp.resultReporter.ReportWaiting(plcAction, outcome.Waiting), nil
 	}
 
 	log.Printf("✅ 로봇에 액션 전송 완료 - Serial: %s, Action: %s", plcAction.SerialNumber, plcAction.Action)
+	return mp.resultReporter.ReportPublished(plcAction, outcome.Command), nil
+}
+
+// broadcastPLCAction sends a PLC action to every robot of a group or the fleet, each under its busy policy,
+// and reports one result with the outcome per robot
+func (mp *MessageProcessor) broadcastPLCAction(plcAction *PLCActionMessage, robotTargets []string) PLCActionResult {
+	log.Printf("📡 PLC 액션 일괄 전송 - Action: %s, Target: %s, Robots: %v", plcAction.Action, plcAction.SerialNumber, robotTargets)
+
+	robots := make([]RobotActionResult, 0, len(robotTargets))
+	for _, robotTarget := range robotTargets {
+		// Later progress is reported per robot; an MQTT requester gets the aggregated result only,
+		// the HTTP API keeps all results of the request
+		robotAction := *plcAction
+		robotAction.SerialNumber = robotTarget
+		if plcAction.Reply == nil || !plcAction.Reply.API {
+			robotAction.Reply = nil
+		}
+
+		result := RobotActionResult{Target: robotTarget}
+		outcome, err := mp.submitAction(&robotAction, robotTarget)
+		switch {
+		case errors.Is(err, ErrRobotBusy):
+			result.Status = ResultRejected
+			result.Reason = err.Error()
+		case err != nil:
+			result.Status = ResultFailed
+			result.Reason = err.Error()
+		case outcome.Waiting != nil:
+			result.Status = ResultQueued
+			result.WaitingID = outcome.Waiting.ID
+			result.Reason = outcome.Waiting.WaitReason()
+		default:
+			published := mp.resultReporter.TrackPublished(&robotAction, outcome.Command)
+			result.Status = ResultPublished
+			result.OrderID = published.OrderID
+			result.ActionIDs = published.ActionIDs
+		}
+		if result.Reason != "" {
+			log.Printf("   %s: %s (%s)", robotTarget, result.Status, result.Reason)
+		}
+		robots = append(robots, result)
+	}
+
+	return mp.resultReporter.ReportBroadcast(plcAction, robots)
+}
+
+// submitAction sends an action to its robot, applying the action's busy policy while the robot executes an order
+func (mp *MessageProcessor) submitAction(plcAction *PLCActionMessage, target string) (*DispatchOutcome, error) {
+	mp.dispatchMutex.Lock()
+	defer mp.dispatchMutex.Unlock()
+
+	robotID, err := mp.robotManager.ResolveRobotID(target)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := mp.actionHandler.BusyPolicy(plcAction.Action, BusyPolicy(mp.config.App.OrderBusyPolicy))
+	if err != nil {
+		return nil, err
+	}
+
+	// Commands wait behind earlier waiting ones; preempting commands only wait for the robot
+	busyReason, busy := mp.robotBusyReason(robotID)
+	waiting := mp.dispatcher.HasWaiting(robotID)
+	if policy == BusySend || (!busy && (!waiting || policy == BusyPreempt)) {
+		command, err := mp.sendActionToRobot(plcAction, robotID)
+		if err != nil {
+			return nil, err
+		}
+		return &DispatchOutcome{Command: command}, nil
+	}
+
+	switch policy {
+	case BusyReject:
+		if !busy {
+			busyReason = fmt.Sprintf("robot %s has commands waiting", robotID)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrRobotBusy, busyReason)
+
+	case BusyPreempt:
+		queued := mp.dispatcher.Enqueue(robotID, plcAction, policy)
+		if err := mp.sendCancelOrder(robotID); err != nil {
+			mp.dispatcher.Remove(robotID, queued.ID)
+			return nil, fmt.Errorf("failed to cancel current order: %w", err)
+		}
+		mp.dispatcher.MarkCancelSent(robotID, queued.ID)
+		log.Printf("⏭️  선점 명령 대기 - Robot: %s, Action: %s, ID: %d (%s)", robotID, plcAction.Action, queued.ID, busyReason)
+		return &DispatchOutcome{Waiting: &queued}, nil
+
+	default:
+		queued := mp.dispatcher.Enqueue(robotID, plcAction, policy)
+		log.Printf("🚦 로봇 작업 완료 대기 - Robot: %s, Action: %s, ID: %d (%s)", robotID, plcAction.Action, queued.ID, busyReason)
+		return &DispatchOutcome{Waiting: &queued}, nil
+	}
 }
 
-// sendActionToRobot sends action to a specific robot
-func (mp *MessageProcessor) sendActionToRobot(plcAction *PLCActionMessage, serialNumber string) error {
-	// Check if robot is online and is target robot
-	if !mp.robotManager.IsTargetRobot(serialNumber) {
-		return fmt.Errorf("robot %s is not in target list", serialNumber)
+// robotBusyReason reports whether a robot is executing an order as seen in its state messages,
+// or was just sent one that its state does not show yet
+func (mp *MessageProcessor) robotBusyReason(robotID string) (string, bool) {
+	if orderID, pending := mp.dispatcher.PendingOrder(robotID); pending {
+		return fmt.Sprintf("order %s was just sent to robot %s", orderID, robotID), true
 	}
 
-	if !mp.robotManager.IsRobotOnline(serialNumber) {
-		return fmt.Errorf("robot %s is not online", serialNumber)
+	robot, exists := mp.robotManager.GetRobotStatus(robotID)
+	if !exists || !robot.IsExecutingOrder {
+		return "", false
 	}
+	// A finished order keeps its ID in the state; it is running while nodes or edges remain
+	if robot.DetailedStatus != nil && len(robot.DetailedStatus.NodeStates) == 0 && len(robot.DetailedStatus.EdgeStates) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("robot %s is executing order %s", robotID, robot.CurrentOrderID), true
+}
 
-	// Convert PLC action to robot action
-	robotAction, err := mp.actionHandler.ConvertPLCActionToRobotAction(plcAction, serialNumber)
+// sendCancelOrder sends cancelOrder to a robot to preempt its current order
+func (mp *MessageProcessor) sendCancelOrder(robotID string) error {
+	robot, exists := mp.robotManager.GetRobotStatus(robotID)
+	if !exists || robot.ConnectionState != Online {
+		return fmt.Errorf("robot %s is not online", robotID)
+	}
+
+	command := mp.actionHandler.createCancelOrderAction(robot.SerialNumber, robot.Manufacturer)
+	topic, err := mp.publishRobotCommand(command)
 	if err != nil {
-		return fmt.Errorf("action conversion failed: %w", err)
+		return err
 	}
 
-	// Convert to JSON
-	payload, err := json.Marshal(robotAction)
+	log.Printf("⏹️  현재 주문 취소 발행 (선점) - Topic: %s, Robot: %s, OrderID: %s", topic, robotID, robot.CurrentOrderID)
+	return nil
+}
+
+// RunDispatcher sends waiting commands and expires stale ones until the context is cancelled
+// State messages release commands right away; this also covers robots that stopped reporting
+func (mp *MessageProcessor) RunDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(commandQueueCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, robotID := range mp.dispatcher.Robots() {
+				mp.releaseWaitingCommands(robotID)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// releaseWaitingCommands sends the waiting commands of a robot in order once it no longer executes an order
+// Commands that outlive their TTL, and preempting commands whose cancelOrder does not take effect, are reported as FAILED
+func (mp *MessageProcessor) releaseWaitingCommands(robotID string) {
+	mp.dispatchMutex.Lock()
+	defer mp.dispatchMutex.Unlock()
+
+	for {
+		waiting, exists := mp.dispatcher.Head(robotID)
+		if !exists {
+			return
+		}
+		plcAction := waiting.Action
+
+		if time.Now().After(waiting.ExpiresAt) {
+			log.Printf("⌛ 대기 명령 만료 - Robot: %s, Action: %s, ID: %d", robotID, plcAction.Action, waiting.ID)
+			mp.dispatcher.Remove(robotID, waiting.ID)
+			mp.resultReporter.ReportFailed(&plcAction, fmt.Errorf("command expired after waiting %s for robot %s",
+				waiting.ExpiresAt.Sub(waiting.QueuedAt), robotID))
+			continue
+		}
+
+		robot, exists := mp.robotManager.GetRobotStatus(robotID)
+		if !exists || robot.ConnectionState != Online {
+			return
+		}
+
+		if _, busy := mp.robotBusyReason(robotID); busy {
+			if waiting.Policy == BusyPreempt && time.Since(waiting.CancelSentAt) > dispatchStateTimeout {
+				log.Printf("❌ 선점 실패 - 주문이 취소되지 않음 - Robot: %s, Action: %s, ID: %d", robotID, plcAction.Action, waiting.ID)
+				mp.dispatcher.Remove(robotID, waiting.ID)
+				mp.resultReporter.ReportFailed(&plcAction, fmt.Errorf("current order of robot %s was not cancelled within %s",
+					robotID, dispatchStateTimeout))
+				continue
+			}
+			return
+		}
+
+		mp.dispatcher.Remove(robotID, waiting.ID)
+		log.Printf("🚦 대기 명령 전송 - Robot: %s, Action: %s, 대기 시간: %s",
+			robotID, plcAction.Action, time.Since(waiting.QueuedAt).Round(time.Second))
+
+		command, err := mp.sendActionToRobot(&plcAction, robotID)
+		if err != nil {
+			log.Printf("❌ 대기 명령 전송 실패 - Robot: %s, Error: %v", robotID, err)
+			mp.resultReporter.ReportFailed(&plcAction, err)
+			continue
+		}
+		mp.resultReporter.ReportPublished(&plcAction, command)
+
+		// The robot is busy with the new order until its state shows it finished
+		if command.Kind == CommandOrder {
+			return
+		}
+	}
+}
+
+// CancelWaitingCommand removes a command waiting for a busy robot and reports it as FAILED
+func (mp *MessageProcessor) CancelWaitingCommand(robotID string, id int64) (WaitingCommand, bool) {
+	mp.dispatchMutex.Lock()
+	defer mp.dispatchMutex.Unlock()
+
+	waiting, exists := mp.dispatcher.Remove(robotID, id)
+	if !exists {
+		return WaitingCommand{}, false
+	}
+
+	plcAction := waiting.Action
+	mp.resultReporter.ReportFailed(&plcAction, fmt.Errorf("cancelled while waiting for robot %s", robotID))
+	log.Printf("🗑️  대기 명령 취소 - Robot: %s, Action: %s, ID: %d", robotID, plcAction.Action, id)
+	return waiting, true
+}
+
+// shouldQueue reports whether an accepted PLC action must wait in the outbound queue
+func (mp *MessageProcessor) shouldQueue(plcAction *PLCActionMessage) bool {
+	if mp.commandQueue == nil {
+		return false
+	}
+	if mp.commandQueue.HasPending(mp.targetRobotIDs(plcAction.SerialNumber), mp.targetRobotIDs) {
+		return true
+	}
+	if isFanOutTarget(plcAction.SerialNumber) {
+		return !mp.connections.RobotsConnected()
+	}
+
+	// Unknown targets are sent right away so the error is reported immediately
+	robotID, err := mp.robotManager.ResolveRobotID(plcAction.SerialNumber)
 	if err != nil {
-		return fmt.Errorf("JSON marshaling failed: %w", err)
+		return false
 	}
+	return !mp.connections.ForRobot(robotID).IsConnected()
+}
 
-	// Determine topic based on action type
-	var topic string
-	if plcAction.Action == "cancelOrder" {
-		topic = buildRobotOrderTopic(serialNumber)
-	} else {
-		topic = buildRobotActionTopic(serialNumber)
+// targetRobotIDs resolves a command target to the manufacturer/serial IDs of the robots it addresses,
+// so that "DEX0001", "Roboligent/DEX0001" and a group containing the robot keep their order in the queue
+// Serials of robots that never registered use the configured manufacturer
+func (mp *MessageProcessor) targetRobotIDs(target string) []string {
+	entries, _, err := mp.robotManager.ExpandTarget(target)
+	if err != nil {
+		return []string{target}
+	}
+
+	robotIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			robotIDs = append(robotIDs, entry)
+			continue
+		}
+		if robotID, err := mp.robotManager.ResolveRobotID(entry); err == nil {
+			robotIDs = append(robotIDs, robotID)
+			continue
+		}
+		robotIDs = append(robotIDs, makeRobotID(mp.topics.Manufacturer(), entry))
+	}
+	return robotIDs
+}
+
+// RunCommandQueue delivers queued commands and expires stale ones until the context is cancelled
+func (mp *MessageProcessor) RunCommandQueue(ctx context.Context) {
+	ticker := time.NewTicker(commandQueueCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			mp.deliverQueuedCommands()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverQueuedCommands sends queued commands whose robot is reachable again, in arrival order per target
+// Commands that outlive their TTL are reported as FAILED
+func (mp *MessageProcessor) deliverQueuedCommands() {
+	held := make(map[string]bool) // robots with an undeliverable command; later ones wait behind it
+
+	for _, queued := range mp.commandQueue.Pending() {
+		target := queued.Action.SerialNumber
+		robotIDs := mp.targetRobotIDs(target)
+		if slices.ContainsFunc(robotIDs, func(robotID string) bool { return held[robotID] }) {
+			continue
+		}
+
+		plcAction := queued.Action
+		plcAction.Reply = queued.Reply
+
+		if time.Now().After(queued.ExpiresAt) {
+			log.Printf("⌛ 큐 명령 만료 - Action: %s, Target: %s, QueuedAt: %s",
+				plcAction.Action, target, queued.QueuedAt.Format(time.RFC3339))
+			mp.resultReporter.ReportFailed(&plcAction, fmt.Errorf("command expired in outbound queue after %s",
+				queued.ExpiresAt.Sub(queued.QueuedAt)))
+			mp.removeQueuedCommand(queued.ID)
+			continue
+		}
+
+		if !mp.isDeliverable(target) {
+			for _, robotID := range robotIDs {
+				held[robotID] = true
+			}
+			continue
+		}
+
+		log.Printf("📦 큐 명령 전송 - Action: %s, Target: %s, 대기 시간: %s",
+			plcAction.Action, target, time.Since(queued.QueuedAt).Round(time.Second))
+		mp.dispatchPLCAction(&plcAction)
+		mp.removeQueuedCommand(queued.ID)
+	}
+}
+
+// isDeliverable reports whether the robot of a queued command is online on a connected broker
+// Group and fleet commands only need a connected robot side broker; each robot then reports its own outcome
+func (mp *MessageProcessor) isDeliverable(target string) bool {
+	if isFanOutTarget(target) {
+		return mp.connections.RobotsConnected()
+	}
+	robotID, err := mp.robotManager.ResolveRobotID(target)
+	if err != nil {
+		return false
+	}
+	if !mp.connections.ForRobot(robotID).IsConnected() {
+		return false
+	}
+	robot, exists := mp.robotManager.GetRobotStatus(robotID)
+	return exists && robot.ConnectionState == Online
+}
+
+// removeQueuedCommand removes a delivered or expired command from the outbound queue
+func (mp *MessageProcessor) removeQueuedCommand(id int64) {
+	if err := mp.commandQueue.Remove(id); err != nil {
+		log.Printf("⚠️  명령 큐 갱신 실패 - ID: %d, Error: %v", id, err)
+	}
+}
+
+// sendActionToRobot sends action to a specific robot and returns the published command
+// The target is either a serial number or "manufacturer/serial"
+func (mp *MessageProcessor) sendActionToRobot(plcAction *PLCActionMessage, target string) (*RobotCommand, error) {
+	// Check if robot is target robot and resolve its manufacturer
+	robotID, err := mp.robotManager.ResolveRobotID(target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if robot is online
+	robot, exists := mp.robotManager.GetRobotStatus(robotID)
+	if !exists || robot.ConnectionState != Online {
+		return nil, fmt.Errorf("robot %s is not online", robotID)
+	}
+
+	// Convert PLC action to robot command addressed to the robot's manufacturer
+	command, err := mp.convertPLCAction(plcAction, robot, robotID)
+	if err != nil {
+		return nil, fmt.Errorf("action conversion failed: %w", err)
 	}
 
-	// Publish to appropriate topic
-	if err := mp.mqttClient.Publish(topic, payload); err != nil {
-		return fmt.Errorf("MQTT publish failed: %w", err)
+	// Check generated actions against the robot's advertised capabilities
+	if err := mp.validateAgainstFactsheet(command, plcAction, robotID); err != nil {
+		return nil, fmt.Errorf("factsheet validation failed: %w", err)
+	}
+
+	// Publish to the topic matching the command kind
+	topic, err := mp.publishRobotCommand(command)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remember issued orders for lifecycle tracking, and treat the robot as busy until its state shows them
+	if command.Kind == CommandOrder {
+		mp.orderTracker.TrackOrder(plcAction.Action, command.Order)
+		mp.dispatcher.NoteOrderSent(robotID, command.OrderID())
 	}
 
 	log.Printf("📤 로봇 액션 메시지 발행 - Topic: %s, HeaderID: %d, ActionType: %s",
-		topic, robotAction.HeaderID, mp.getActionTypeForLogging(robotAction))
+		topic, command.Header().HeaderID, mp.getActionTypeForLogging(command))
 
-	return nil
+	return command, nil
 }
 
-// getActionTypeForLogging extracts action type for logging purposes
-func (mp *MessageProcessor) getActionTypeForLogging(robotAction *RobotActionMessage) string {
-	if len(robotAction.Actions) > 0 {
-		return robotAction.Actions[0].ActionType
+// convertPLCAction builds a new robot command, or an update of the robot's running order for "EXT:" actions
+func (mp *MessageProcessor) convertPLCAction(plcAction *PLCActionMessage, robot *RobotStatus, robotID string) (*RobotCommand, error) {
+	if !IsOrderUpdateAction(plcAction.Action) {
+		return mp.actionHandler.ConvertPLCActionToRobotCommand(plcAction, robot.SerialNumber, robot.Manufacturer)
+	}
+
+	base, err := mp.orderTracker.GetExtendableOrder(robotID)
+	if err != nil {
+		return nil, err
+	}
+	if robot.CurrentOrderID != "" && robot.CurrentOrderID != base.OrderID {
+		return nil, fmt.Errorf("robot %s is executing order %s, not %s", robotID, robot.CurrentOrderID, base.OrderID)
+	}
+
+	log.Printf("🧩 주문 업데이트 생성 - Robot: %s, OrderID: %s, OrderUpdateID: %d -> %d",
+		robotID, base.OrderID, base.OrderUpdateID, base.OrderUpdateID+1)
+	return mp.actionHandler.ConvertPLCActionToOrderUpdate(plcAction, base)
+}
+
+// validateAgainstFactsheet validates a robot message against the factsheet when strict mode is enabled
+func (mp *MessageProcessor) validateAgainstFactsheet(command *RobotCommand, plcAction *PLCActionMessage, robotID string) error {
+	if !mp.config.App.StrictFactsheetCheck || plcAction.Action == "factsheetRequest" {
+		return nil
+	}
+
+	factsheet, exists := mp.robotManager.GetRobotFactsheet(robotID)
+	if !exists {
+		log.Printf("⚠️  Factsheet 미수신 - 엄격 검증 생략: %s", robotID)
+		return nil
+	}
+
+	return validateRobotCommandAgainstFactsheet(command, factsheet)
+}
+
+// publishRobotCommand publishes an order to the orders topic and instant actions to the instantActions topic
+// of the manufacturer and serial number in the command header
+func (mp *MessageProcessor) publishRobotCommand(command *RobotCommand) (string, error) {
+	// Convert to JSON
+	payload, err := json.Marshal(command.Payload())
+	if err != nil {
+		return "", fmt.Errorf("JSON marshaling failed: %w", err)
+	}
+
+	// Determine topic based on command kind
+	header := command.Header()
+	var topic string
+	if command.Kind == CommandOrder {
+		topic = mp.topics.RobotOrderTopic(header.Manufacturer, header.SerialNumber)
+	} else {
+		topic = mp.topics.RobotInstantActionsTopic(header.Manufacturer, header.SerialNumber)
 	}
-	if len(robotAction.Nodes) > 0 && len(robotAction.Nodes[0].Actions) > 0 {
-		return robotAction.Nodes[0].Actions[0].ActionType
+
+	// Let the broker drop commands that could not be delivered in time instead of replaying stale orders
+	mqttClient := mp.connections.ForRobot(makeRobotID(header.Manufacturer, header.SerialNumber))
+	options := PublishOptions{MessageExpiry: time.Duration(mqttClient.config.CommandExpirySec) * time.Second}
+	if err := mqttClient.PublishWithOptions(topic, payload, options); err != nil {
+		return "", fmt.Errorf("MQTT publish failed: %w", err)
+	}
+	return topic, nil
+}
+
+// getActionTypeForLogging extracts action type for logging purposes
+func (mp *MessageProcessor) getActionTypeForLogging(command *RobotCommand) string {
+	if actions := command.AllActions(); len(actions) > 0 {
+		return actions[0].ActionType
 	}
 	return "unknown"
 }
 
+// SendInitPosition sends an initPosition instant action with the given pose to an online robot
+func (mp *MessageProcessor) SendInitPosition(robotID string, pose Pose) error {
+	robot, exists := mp.robotManager.GetRobotStatus(robotID)
+	if !exists || robot.ConnectionState != Online {
+		return fmt.Errorf("robot %s is not online", robotID)
+	}
+
+	command := mp.actionHandler.createInitPositionAction(robot.SerialNumber, robot.Manufacturer, pose)
+	if err := mp.validateAgainstFactsheet(command, &PLCActionMessage{Action: "init"}, robotID); err != nil {
+		return fmt.Errorf("factsheet validation failed: %w", err)
+	}
+
+	topic, err := mp.publishRobotCommand(command)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("📤 위치 초기화 발행 - Topic: %s, HeaderID: %d", topic, command.Header().HeaderID)
+	return nil
+}
+
 // SendFactsheetRequest sends factsheet request to a specific robot
 func (mp *MessageProcessor) SendFactsheetRequest(serialNumber string, manufacturer string) error {
 	// Create factsheet request
 	factsheetRequest := mp.actionHandler.createFactsheetRequestAction(serialNumber, manufacturer)
 
-	// Convert to JSON
-	payload, err := json.Marshal(factsheetRequest)
+	// Publish to instantActions topic
+	topic, err := mp.publishRobotCommand(factsheetRequest)
 	if err != nil {
-		return fmt.Errorf("JSON marshaling failed: %w", err)
-	}
-
-	// Build topic and publish
-	topic := buildRobotActionTopic(serialNumber)
-	if err := mp.mqttClient.Publish(topic, payload); err != nil {
-		return fmt.Errorf("MQTT publish failed: %w", err)
+		return err
 	}
 
 	log.Printf("📤 Factsheet 요청 발행 - Topic: %s, HeaderID: %d",
-		topic, factsheetRequest.HeaderID)
+		topic, factsheetRequest.Header().HeaderID)
 
 	return nil
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestMessageProcessor wires a message processor with request deduplication to a single broker
+// connection and an online robot Roboligent/DEX0001
+func newTestMessageProcessor(t *testing.T, busyPolicy BusyPolicy) (*MessageProcessor, *MQTTClient) {
+	t.Helper()
+	topics, err := NewTopicLayout(newTestTopicConfig("{interfaceName}/{majorVersion}/{manufacturer}/{serialNumber}/{topic}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &MQTTClient{client: &recordingTransport{}, config: &MQTTConfig{Name: "test"}, status: Connected}
+	connections := &BrokerConnections{
+		clients:      []*MQTTClient{client},
+		plcClient:    client,
+		robotClients: []*MQTTClient{client},
+		robotHomes:   make(map[string]*MQTTClient),
+	}
+
+	robotManager := NewRobotManager([]string{"DEX0001"}, nil, nil)
+	robotManager.UpdateRobotConnectionStatus(&RobotConnectionMessage{
+		Manufacturer: "Roboligent", SerialNumber: "DEX0001", ConnectionState: Online,
+	})
+
+	resultReporter := NewActionResultReporter(client, topics)
+	config := &Config{App: AppConfig{OrderBusyPolicy: string(busyPolicy)}}
+	mp := NewMessageProcessor(connections, topics, robotManager, newTestActionHandler(t), NewOrderTracker(),
+		resultReporter, NewRobotDispatcher(time.Minute), config)
+
+	deduplicator := NewRequestDeduplicator(time.Minute)
+	mp.SetRequestDeduplicator(deduplicator)
+	resultReporter.SetResultCallback(deduplicator.HandleResult)
+	return mp, client
+}
+
+func TestSubmitPLCActionRetriesTransientRejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		block   func(mp *MessageProcessor, client *MQTTClient) // makes the first delivery fail
+		unblock func(mp *MessageProcessor, client *MQTTClient)
+		wantErr error
+	}{
+		{
+			name:    "robot broker down",
+			block:   func(mp *MessageProcessor, client *MQTTClient) { client.updateStatus(ConnectionLost) },
+			unblock: func(mp *MessageProcessor, client *MQTTClient) { client.updateStatus(Connected) },
+			wantErr: errRobotsDisconnected,
+		},
+		{
+			name: "robot busy",
+			block: func(mp *MessageProcessor, client *MQTTClient) {
+				mp.dispatcher.NoteOrderSent("Roboligent/DEX0001", "order-0")
+			},
+			unblock: func(mp *MessageProcessor, client *MQTTClient) {
+				mp.dispatcher.NoteRobotState("Roboligent/DEX0001", "order-0")
+			},
+			wantErr: ErrRobotBusy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, client := newTestMessageProcessor(t, BusyReject)
+			plcAction := func() *PLCActionMessage {
+				return &PLCActionMessage{SerialNumber: "DEX0001", Action: "I:inference1", RequestID: "req-1"}
+			}
+
+			tt.block(mp, client)
+			result, err := mp.SubmitPLCAction(plcAction())
+			if !errors.Is(err, tt.wantErr) || result.Status != ResultRejected {
+				t.Fatalf("first delivery = (%s, %v), want REJECTED with %v", result.Status, err, tt.wantErr)
+			}
+
+			// The redelivery of the same request id is dispatched, not answered with the stale rejection
+			tt.unblock(mp, client)
+			result, err = mp.SubmitPLCAction(plcAction())
+			if err != nil || result.Duplicate || result.Status != ResultPublished {
+				t.Fatalf("redelivery = (%s, duplicate %t, %v), want PUBLISHED", result.Status, result.Duplicate, err)
+			}
+
+			// Once dispatched, further redeliveries are duplicates again
+			result, _ = mp.SubmitPLCAction(plcAction())
+			if !result.Duplicate || result.Status != ResultPublished {
+				t.Fatalf("second redelivery = (%s, duplicate %t), want duplicate PUBLISHED", result.Status, result.Duplicate)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ConnectionState represents the robot's connection state
 type ConnectionState string
@@ -99,6 +102,21 @@ type BatteryState struct {
 	BatteryHealth  int     `json:"batteryHealth"`
 	Charging       bool    `json:"charging"` // 실제 메시지에서 사용하는 필드명
 	Reach          int     `json:"reach"`
+
+	reported bool // state 메시지에 batteryState가 포함되었는지 여부 (없으면 충전량 0은 의미 없음)
+}
+
+// UnmarshalJSON decodes the battery state and records that the robot reported one
+func (bs *BatteryState) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	type batteryState BatteryState
+	if err := json.Unmarshal(data, (*batteryState)(bs)); err != nil {
+		return err
+	}
+	bs.reported = true
+	return nil
 }
 
 // SafetyState represents robot's safety status
@@ -129,6 +147,7 @@ type InfoMessage struct {
 
 // RobotStatus holds the current status of a robot
 type RobotStatus struct {
+	RobotID         string          `json:"robotId"` // manufacturer/serial
 	SerialNumber    string          `json:"serialNumber"`
 	Manufacturer    string          `json:"manufacturer"`
 	ConnectionState ConnectionState `json:"connectionState"`
@@ -174,26 +193,154 @@ type RobotStatus struct {
 
 // PLCActionMessage represents the message from PLC bridge/actions topic
 type PLCActionMessage struct {
-	Action       string `json:"action"`
-	SerialNumber string `json:"serialNumber"` // Required in new format
+	Action       string          `json:"action"`
+	SerialNumber string          `json:"serialNumber"`        // Required in new format, "serial" or "manufacturer/serial"
+	RequestID    string          `json:"requestId,omitempty"` // Optional PLC id; repeated deliveries are not dispatched again
+	Priority     int             `json:"priority,omitempty"`  // Higher priority commands wait ahead of lower ones for a busy robot
+	Reply        *ResponseTarget `json:"-"`                   // MQTT 5 response topic or HTTP API of the request, nil if not requested
+
+	// JSON form only; NormalizePLCAction folds them into Action
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Station    string            `json:"station,omitempty"`
+}
+
+// ResponseTarget is where the results of a request are answered directly: an MQTT 5 response topic,
+// or the HTTP API for commands submitted there
+type ResponseTarget struct {
+	Topic           string            `json:"topic"`
+	CorrelationData []byte            `json:"correlationData,omitempty"`
+	UserProperties  map[string]string `json:"userProperties,omitempty"` // Request user properties echoed on every response
+	API             bool              `json:"api,omitempty"`            // HTTP API command: results are kept for GET /actions/{requestId}, not published
+}
+
+// ActionResultStatus represents the processing stage of a PLC command reported back to the PLC
+type ActionResultStatus string
+
+const (
+	ResultAccepted  ActionResultStatus = "ACCEPTED"
+	ResultQueued    ActionResultStatus = "QUEUED"
+	ResultRejected  ActionResultStatus = "REJECTED"
+	ResultPublished ActionResultStatus = "PUBLISHED"
+	ResultRunning   ActionResultStatus = "RUNNING"
+	ResultFinished  ActionResultStatus = "FINISHED"
+	ResultFailed    ActionResultStatus = "FAILED"
+)
+
+// PLCActionResult represents the result message published to the PLC for each command
+type PLCActionResult struct {
+	SerialNumber string              `json:"serialNumber"`
+	Action       string              `json:"action"`
+	RequestID    string              `json:"requestId,omitempty"`
+	Status       ActionResultStatus  `json:"status"`
+	OrderID      string              `json:"orderId,omitempty"`
+	ActionIDs    []string            `json:"actionIds,omitempty"`
+	Reason       string              `json:"reason,omitempty"`
+	WaitingID    int64               `json:"waitingId,omitempty"` // ID of a command waiting for a busy robot
+	Duplicate    bool                `json:"duplicate,omitempty"` // latest result of the original request, repeated for a redelivery
+	Robots       []RobotActionResult `json:"robots,omitempty"`    // per-robot outcome of a group or fleet command
+	Timestamp    string              `json:"timestamp"`
+
+	reply *ResponseTarget // response target of the originating request
 }
 
-// RobotActionMessage represents the message to robot
-type RobotActionMessage struct {
+// RobotActionResult is the outcome of a group or fleet command for one robot
+// Later RUNNING/FINISHED/FAILED results are reported per robot with the robot as serial number
+type RobotActionResult struct {
+	Target    string             `json:"target"`
+	Status    ActionResultStatus `json:"status"`
+	OrderID   string             `json:"orderId,omitempty"`
+	ActionIDs []string           `json:"actionIds,omitempty"`
+	WaitingID int64              `json:"waitingId,omitempty"`
+	Reason    string             `json:"reason,omitempty"`
+}
+
+// MessageHeader represents the VDA5050 header shared by all messages sent to robots
+type MessageHeader struct {
 	HeaderID     int    `json:"headerId"`
 	Timestamp    string `json:"timestamp"`
 	Version      string `json:"version"`
 	Manufacturer string `json:"manufacturer"`
 	SerialNumber string `json:"serialNumber"`
+}
+
+// OrderMessage represents a VDA5050 2.0 order published to the orders topic
+type OrderMessage struct {
+	MessageHeader
+	OrderID       string `json:"orderId"`
+	OrderUpdateID int    `json:"orderUpdateId"`
+	ZoneSetID     string `json:"zoneSetId,omitempty"`
+	Nodes         []Node `json:"nodes"`
+	Edges         []Edge `json:"edges"`
+}
+
+// InstantActionsMessage represents a VDA5050 2.0 instant actions message published to the instantActions topic
+type InstantActionsMessage struct {
+	MessageHeader
+	Actions []Action `json:"actions"`
+}
+
+// RobotCommandKind determines which VDA5050 topic a robot command is published to
+type RobotCommandKind string
+
+const (
+	CommandOrder          RobotCommandKind = "order"
+	CommandInstantActions RobotCommandKind = "instantActions"
+)
+
+// RobotCommand is the typed result of converting a PLC action; exactly one message is set
+type RobotCommand struct {
+	Kind           RobotCommandKind
+	Order          *OrderMessage
+	InstantActions *InstantActionsMessage
+}
+
+// Header returns the header of the contained message
+func (rc *RobotCommand) Header() MessageHeader {
+	if rc.Kind == CommandOrder {
+		return rc.Order.MessageHeader
+	}
+	return rc.InstantActions.MessageHeader
+}
+
+// Payload returns the message to be serialized
+func (rc *RobotCommand) Payload() interface{} {
+	if rc.Kind == CommandOrder {
+		return rc.Order
+	}
+	return rc.InstantActions
+}
+
+// OrderID returns the order id for order commands and an empty string otherwise
+func (rc *RobotCommand) OrderID() string {
+	if rc.Kind == CommandOrder {
+		return rc.Order.OrderID
+	}
+	return ""
+}
 
-	// For simple actions (like init and factsheetRequest)
-	Actions []Action `json:"actions,omitempty"`
+// AllActions returns every action contained in the command in message order
+func (rc *RobotCommand) AllActions() []Action {
+	if rc.Kind == CommandInstantActions {
+		return rc.InstantActions.Actions
+	}
+
+	var actions []Action
+	for _, node := range rc.Order.Nodes {
+		actions = append(actions, node.Actions...)
+	}
+	for _, edge := range rc.Order.Edges {
+		actions = append(actions, edge.Actions...)
+	}
+	return actions
+}
 
-	// For order-based actions (like inference and trajectory)
-	OrderID       string `json:"orderId,omitempty"`
-	OrderUpdateID int    `json:"orderUpdateId,omitempty"`
-	Nodes         []Node `json:"nodes,omitempty"`
-	Edges         []Edge `json:"edges,omitempty"`
+// ActionIDs returns the ids of all actions contained in the command
+func (rc *RobotCommand) ActionIDs() []string {
+	var actionIDs []string
+	for _, action := range rc.AllActions() {
+		actionIDs = append(actionIDs, action.ActionID)
+	}
+	return actionIDs
 }
 
 // Action represents a robot action (used in both simple actions and node actions)
@@ -208,7 +355,7 @@ type Action struct {
 // Node represents a robot navigation node
 type Node struct {
 	NodeID       string       `json:"nodeId"`
-	Description  string       `json:"description"`
+	Description  string       `json:"nodeDescription,omitempty"`
 	SequenceID   int          `json:"sequenceId"`
 	Released     bool         `json:"released"`
 	NodePosition NodePosition `json:"nodePosition"`
@@ -323,3 +470,82 @@ type TypeSpecification struct {
 	SeriesDescription string   `json:"SeriesDescription"`
 	SeriesName        string   `json:"SeriesName"`
 }
+
+// OrderLifecycleStatus represents the lifecycle state of an order issued by the bridge
+type OrderLifecycleStatus string
+
+const (
+	OrderWaiting      OrderLifecycleStatus = "WAITING"      // 발행됨, 로봇이 아직 수락하지 않음
+	OrderInitializing OrderLifecycleStatus = "INITIALIZING" // 로봇이 주문을 수락함
+	OrderRunning      OrderLifecycleStatus = "RUNNING"
+	OrderFinished     OrderLifecycleStatus = "FINISHED"
+	OrderFailed       OrderLifecycleStatus = "FAILED"
+	OrderVanished     OrderLifecycleStatus = "VANISHED" // 완료되지 않은 채 로봇 상태에서 사라짐
+	OrderReplaced     OrderLifecycleStatus = "REPLACED" // 다른 주문으로 대체됨
+)
+
+// IsTerminal reports whether the order will not change state anymore
+func (s OrderLifecycleStatus) IsTerminal() bool {
+	switch s {
+	case OrderFinished, OrderFailed, OrderVanished, OrderReplaced:
+		return true
+	default:
+		return false
+	}
+}
+
+// StatusTransition records when a tracked element entered a status
+type StatusTransition struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TrackedNode represents the progress of an order node (PENDING -> TRAVERSED)
+type TrackedNode struct {
+	NodeID        string             `json:"nodeId"`
+	SequenceID    int                `json:"sequenceId"`
+	OrderUpdateID int                `json:"orderUpdateId"` // 노드를 추가한 주문 업데이트
+	Status        string             `json:"status"`
+	Transitions   []StatusTransition `json:"transitions"`
+}
+
+// TrackedEdge represents the progress of an order edge (PENDING -> TRAVERSED)
+type TrackedEdge struct {
+	EdgeID        string             `json:"edgeId"`
+	SequenceID    int                `json:"sequenceId"`
+	OrderUpdateID int                `json:"orderUpdateId"` // 엣지를 추가한 주문 업데이트
+	Status        string             `json:"status"`
+	Transitions   []StatusTransition `json:"transitions"`
+}
+
+// TrackedAction represents the progress of an action within an order
+type TrackedAction struct {
+	ActionID          string             `json:"actionId"`
+	ActionType        string             `json:"actionType"`
+	Status            string             `json:"status"`
+	ResultDescription string             `json:"resultDescription,omitempty"`
+	Transitions       []StatusTransition `json:"transitions"`
+}
+
+// TrackedOrder represents an order issued by the bridge and its observed lifecycle
+type TrackedOrder struct {
+	OrderID       string               `json:"orderId"`
+	OrderUpdateID int                  `json:"orderUpdateId"`
+	SerialNumber  string               `json:"serialNumber"`
+	Manufacturer  string               `json:"manufacturer"`
+	Command       string               `json:"command"`
+	Updates       []string             `json:"updates,omitempty"` // 주문 업데이트로 추가된 PLC 명령
+	Status        OrderLifecycleStatus `json:"status"`
+	Reason        string               `json:"reason,omitempty"`
+	ReplacedBy    string               `json:"replacedBy,omitempty"`
+	IssuedAt      time.Time            `json:"issuedAt"`
+	CompletedAt   time.Time            `json:"completedAt,omitempty"`
+	Transitions   []StatusTransition   `json:"transitions"`
+	Nodes         []TrackedNode        `json:"nodes"`
+	Edges         []TrackedEdge        `json:"edges"`
+	Actions       []TrackedAction      `json:"actions"`
+
+	// Order update support
+	LastNodeSequenceID int  `json:"lastNodeSequenceId"` // 로봇이 보고한 마지막 노드 SequenceID
+	stitchNode         Node // 마지막 base 노드 (주문 업데이트의 첫 노드)
+}
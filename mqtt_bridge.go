@@ -11,11 +11,20 @@ import (
 // MQTTBridge coordinates all bridge components
 type MQTTBridge struct {
 	// Core components
-	mqttClient       *MQTTClient
+	connections      *BrokerConnections
+	topics           *TopicLayout
 	robotManager     *RobotManager
+	positions        *PositionStore
+	commandQueue     *CommandQueue
+	dispatcher       *RobotDispatcher
 	actionHandler    *ActionHandler
+	orderTracker     *OrderTracker
+	resultReporter   *ActionResultReporter
 	messageProcessor *MessageProcessor
 	statusMonitor    *RobotStatusMonitor
+	apiServer        *APIServer
+	fleetStatus      *FleetStatusPublisher
+	heartbeat        *BridgeHeartbeat
 	config           *Config
 
 	// Graceful shutdown
@@ -27,31 +36,89 @@ type MQTTBridge struct {
 	statusMonitorStop chan struct{}
 }
 
-// NewMQTTBridge creates a new MQTT bridge with all components
-func NewMQTTBridge(config *Config) *MQTTBridge {
+// NewMQTTBridge creates a new MQTT bridge with all components using the loaded action catalog and stations
+func NewMQTTBridge(config *Config, catalog *ActionCatalog, stations *StationRegistry) (*MQTTBridge, error) {
+	// Build topic layout shared by subscribe, parse and publish
+	topics, err := NewTopicLayout(&config.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("토픽 레이아웃 생성 실패: %w", err)
+	}
+
+	// Action catalog maps PLC commands to robot actions, orders target its stations with "@station"
+	log.Printf("📚 액션 카탈로그 로드 완료 - Source: %s, Actions: %d개", catalog.Source(), catalog.Len())
+	log.Printf("📍 스테이션 레지스트리 로드 완료 - Source: %s, Stations: %v", stations.Source(), stations.GetStationNames())
+
+	// Load last known robot positions for auto-init
+	positions, err := NewPositionStore(config.App.PositionStoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("로봇 위치 저장소 로드 실패: %w", err)
+	}
+
+	// Open the outbound queue holding commands while the robot side broker is unreachable
+	var commandQueue *CommandQueue
+	if config.App.CommandQueueFile != "" {
+		commandQueue, err = NewCommandQueue(config.App.CommandQueueFile, time.Duration(config.App.CommandQueueTTLSec)*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("명령 큐 로드 실패: %w", err)
+		}
+	}
+
 	// Create shutdown context
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create core components
-	robotManager := NewRobotManager(config.App.TargetRobotSerials)
-	actionHandler := NewActionHandler()
+	robotManager := NewRobotManager(config.App.TargetRobotSerials, config.App.RobotGroups, positions)
+	actionHandler := NewActionHandler(catalog, stations)
+
+	// Create broker connections (without handlers initially)
+	connections, err := NewBrokerConnections(config.Connections, topics)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
 
-	// Create MQTT client (without handlers initially)
-	mqttClient := NewMQTTClient(&config.MQTT, nil)
+	// Create order tracker and result reporter for PLC command feedback
+	orderTracker := NewOrderTracker()
+	resultReporter := NewActionResultReporter(connections.PLC(), topics)
+	orderTracker.SetOrderStatusCallback(resultReporter.HandleOrderStatusChange)
+
+	// Republish robot state changes as events
+	eventPublisher := NewRobotEventPublisher(connections.PLC(), topics)
+	robotManager.SubscribeEvents(eventPublisher.HandleEvent)
+
+	// Serialize commands per robot while it executes an order
+	dispatcher := NewRobotDispatcher(time.Duration(config.App.BusyQueueTTLSec) * time.Second)
 
 	// Create message processor
-	messageProcessor := NewMessageProcessor(mqttClient, robotManager, actionHandler, config)
+	messageProcessor := NewMessageProcessor(connections, topics, robotManager, actionHandler, orderTracker, resultReporter, dispatcher, config)
 
-	// Set message handlers for MQTT client
-	mqttClient.handlers = messageProcessor.GetMessageHandlers()
+	if commandQueue != nil {
+		messageProcessor.SetCommandQueue(commandQueue)
+	}
+
+	// Answer repeated PLC request ids with the original result
+	if config.App.DedupWindowSec > 0 {
+		deduplicator := NewRequestDeduplicator(time.Duration(config.App.DedupWindowSec) * time.Second)
+		messageProcessor.SetRequestDeduplicator(deduplicator)
+		resultReporter.SetResultCallback(deduplicator.HandleResult)
+	}
+
+	// Set message handlers for all broker connections
+	connections.SetHandlers(messageProcessor.GetMessageHandlers())
 
 	// Create status monitor
 	statusMonitor := NewRobotStatusMonitor(robotManager, messageProcessor, config)
 
-	return &MQTTBridge{
-		mqttClient:        mqttClient,
+	bridge := &MQTTBridge{
+		connections:       connections,
+		topics:            topics,
 		robotManager:      robotManager,
+		positions:         positions,
+		commandQueue:      commandQueue,
+		dispatcher:        dispatcher,
 		actionHandler:     actionHandler,
+		orderTracker:      orderTracker,
+		resultReporter:    resultReporter,
 		messageProcessor:  messageProcessor,
 		statusMonitor:     statusMonitor,
 		config:            config,
@@ -59,22 +126,52 @@ func NewMQTTBridge(config *Config) *MQTTBridge {
 		shutdownCancel:    cancel,
 		statusMonitorStop: make(chan struct{}),
 	}
+
+	// Publish retained fleet status for late subscribers
+	bridge.fleetStatus = NewFleetStatusPublisher(bridge, topics.FleetStatusTopic())
+	robotManager.SubscribeEvents(bridge.fleetStatus.HandleEvent)
+	connections.SetStatusCallback(bridge.fleetStatus.HandleConnectionStatus)
+
+	// Publish periodic heartbeat if enabled
+	if config.App.HeartbeatIntervalSec > 0 {
+		bridge.heartbeat = NewBridgeHeartbeat(bridge, topics.BridgeHeartbeatTopic(),
+			time.Duration(config.App.HeartbeatIntervalSec)*time.Second)
+	}
+
+	// Create HTTP API server if enabled
+	if config.App.HTTPListenAddr != "" {
+		bridge.apiServer = NewAPIServer(bridge, config.App.HTTPListenAddr)
+	}
+
+	return bridge, nil
 }
 
 // Start initializes and starts the MQTT bridge
 func (mb *MQTTBridge) Start() error {
 	log.Printf("🚀 MQTT 브릿지 시작 중...")
-	log.Printf("📋 설정 정보 - Broker: %s, ClientID: %s, ConnectTimeout: %ds, MaxReconnectAttempts: %d",
-		mb.config.MQTT.BrokerURL, mb.config.MQTT.ClientID, mb.config.MQTT.ConnectTimeout, mb.config.MQTT.MaxReconnectAttempts)
+	for _, connection := range mb.config.Connections {
+		log.Printf("📋 설정 정보 [%s] - Broker: %s, ClientID: %s, ConnectTimeout: %ds, MaxReconnectAttempts: %d",
+			connection.Name, connection.BrokerURL, connection.ClientID, connection.ConnectTimeout, connection.MaxReconnectAttempts)
+	}
 
-	// Connect to MQTT broker
-	if err := mb.mqttClient.Connect(); err != nil {
+	// Connect to MQTT brokers
+	if err := mb.connections.Connect(); err != nil {
 		return fmt.Errorf("MQTT 연결 실패: %w", err)
 	}
 
+	// Start HTTP API server
+	if mb.apiServer != nil {
+		if err := mb.apiServer.Start(); err != nil {
+			return fmt.Errorf("HTTP API 서버 시작 실패: %w", err)
+		}
+	}
+
 	// Start monitoring components
 	mb.startMonitoring()
 
+	// Publish the initial fleet status
+	mb.fleetStatus.Trigger()
+
 	log.Printf("✅ MQTT 브릿지 시작 완료")
 	return nil
 }
@@ -87,6 +184,38 @@ func (mb *MQTTBridge) startMonitoring() {
 		defer mb.shutdownWG.Done()
 		mb.runUnifiedMonitoring()
 	}()
+
+	// Start retained fleet status publisher
+	mb.shutdownWG.Add(1)
+	go func() {
+		defer mb.shutdownWG.Done()
+		mb.fleetStatus.Run(mb.shutdownCtx)
+	}()
+
+	// Start releasing commands waiting for busy robots
+	mb.shutdownWG.Add(1)
+	go func() {
+		defer mb.shutdownWG.Done()
+		mb.messageProcessor.RunDispatcher(mb.shutdownCtx)
+	}()
+
+	// Start outbound command queue delivery
+	if mb.commandQueue != nil {
+		mb.shutdownWG.Add(1)
+		go func() {
+			defer mb.shutdownWG.Done()
+			mb.messageProcessor.RunCommandQueue(mb.shutdownCtx)
+		}()
+	}
+
+	// Start bridge heartbeat
+	if mb.heartbeat != nil {
+		mb.shutdownWG.Add(1)
+		go func() {
+			defer mb.shutdownWG.Done()
+			mb.heartbeat.Run(mb.shutdownCtx)
+		}()
+	}
 }
 
 // runUnifiedMonitoring runs unified status and health monitoring
@@ -103,12 +232,21 @@ func (mb *MQTTBridge) runUnifiedMonitoring() {
 		select {
 		case <-statusTicker.C:
 			// Combined status monitoring
-			status := mb.mqttClient.GetConnectionStatus()
-			reconnectCount := mb.mqttClient.GetReconnectCount()
-
 			// Print unified status
 			log.Printf("📊 === MQTT 브릿지 상태 ===")
-			log.Printf("   MQTT: %s (재연결: %d회)", status, reconnectCount)
+			for _, connection := range mb.connections.GetConnectionInfos() {
+				log.Printf("   MQTT: %s", connection)
+				if connection.LastReason != nil {
+					log.Printf("   MQTT 마지막 Reason Code [%s]: %s", connection.Name, connection.LastReason)
+				}
+			}
+
+			if waiting := mb.dispatcher.Len(); waiting > 0 {
+				log.Printf("   로봇 작업 완료 대기 명령: %d개", waiting)
+			}
+			if mb.commandQueue != nil {
+				log.Printf("   명령 큐: %d개 대기", mb.commandQueue.Len())
+			}
 
 			// Print robot status summary
 			mb.statusMonitor.PrintStatusSummary()
@@ -116,13 +254,21 @@ func (mb *MQTTBridge) runUnifiedMonitoring() {
 			// Check battery levels
 			mb.statusMonitor.CheckBatteryLevels()
 
+			// Persist last known robot positions
+			if err := mb.positions.Save(); err != nil {
+				log.Printf("⚠️  로봇 위치 저장 실패: %v", err)
+			}
+
+			// Refresh retained fleet status
+			mb.fleetStatus.Trigger()
+
 			log.Printf("   ========================")
 
 		case <-healthTicker.C:
 			// Health check only (no duplicate logging)
-			if !mb.mqttClient.IsConnected() {
+			if !mb.connections.IsConnected() {
 				consecutiveFailures++
-				status := mb.mqttClient.GetConnectionStatus()
+				status := mb.connections.GetConnectionStatus()
 
 				if consecutiveFailures >= maxFailures {
 					log.Printf("🚨 MQTT 연결 심각 - %d회 연속 실패 (상태: %s)", consecutiveFailures, status)
@@ -152,23 +298,40 @@ func (mb *MQTTBridge) Stop() {
 	// Stop monitoring goroutine
 	close(mb.statusMonitorStop)
 
-	// Stop MQTT client
-	mb.mqttClient.Stop()
+	// Stop HTTP API server
+	if mb.apiServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		mb.apiServer.Stop(ctx)
+		cancel()
+	}
+
+	// Stop MQTT clients
+	mb.connections.Stop()
+
+	// Persist last known robot positions
+	if err := mb.positions.Save(); err != nil {
+		log.Printf("⚠️  로봇 위치 저장 실패: %v", err)
+	}
 
 	// Wait for all goroutines to finish
 	mb.shutdownWG.Wait()
 
+	// Queued commands stay in the queue file for the next start
+	if mb.commandQueue != nil {
+		mb.commandQueue.Close()
+	}
+
 	log.Printf("✅ MQTT 브릿지 종료 완료")
 }
 
-// IsConnected checks if the MQTT client is connected
+// IsConnected checks if all MQTT connections are connected
 func (mb *MQTTBridge) IsConnected() bool {
-	return mb.mqttClient.IsConnected()
+	return mb.connections.IsConnected()
 }
 
-// GetConnectionStatus returns the current MQTT connection status
+// GetConnectionStatus returns the combined MQTT connection status
 func (mb *MQTTBridge) GetConnectionStatus() ConnectionStatus {
-	return mb.mqttClient.GetConnectionStatus()
+	return mb.connections.GetConnectionStatus()
 }
 
 // GetRobotManager returns the robot manager instance
@@ -181,14 +344,34 @@ func (mb *MQTTBridge) GetActionHandler() *ActionHandler {
 	return mb.actionHandler
 }
 
+// GetOrderTracker returns the order tracker instance
+func (mb *MQTTBridge) GetOrderTracker() *OrderTracker {
+	return mb.orderTracker
+}
+
+// GetResultReporter returns the action result reporter instance
+func (mb *MQTTBridge) GetResultReporter() *ActionResultReporter {
+	return mb.resultReporter
+}
+
+// GetTopicLayout returns the topic layout instance
+func (mb *MQTTBridge) GetTopicLayout() *TopicLayout {
+	return mb.topics
+}
+
 // GetConfig returns the bridge configuration
 func (mb *MQTTBridge) GetConfig() *Config {
 	return mb.config
 }
 
-// GetMQTTClient returns the MQTT client instance
+// GetMQTTClient returns the MQTT client serving the PLC side
 func (mb *MQTTBridge) GetMQTTClient() *MQTTClient {
-	return mb.mqttClient
+	return mb.connections.PLC()
+}
+
+// GetConnections returns the broker connections
+func (mb *MQTTBridge) GetConnections() *BrokerConnections {
+	return mb.connections
 }
 
 // GetMessageProcessor returns the message processor instance
@@ -201,9 +384,24 @@ func (mb *MQTTBridge) GetStatusMonitor() *RobotStatusMonitor {
 	return mb.statusMonitor
 }
 
-// SendActionToRobot sends an action to a specific robot (public interface)
-func (mb *MQTTBridge) SendActionToRobot(action *PLCActionMessage, serialNumber string) error {
-	return mb.messageProcessor.sendActionToRobot(action, serialNumber)
+// GetDispatcher returns the per-robot command dispatcher
+func (mb *MQTTBridge) GetDispatcher() *RobotDispatcher {
+	return mb.dispatcher
+}
+
+// SubmitAction validates and dispatches a command like a PLC command, reporting to its reply target (public interface)
+func (mb *MQTTBridge) SubmitAction(action *PLCActionMessage) (PLCActionResult, error) {
+	return mb.messageProcessor.SubmitPLCAction(action)
+}
+
+// GetActionResults returns the results reported for a command submitted over the HTTP API (public interface)
+func (mb *MQTTBridge) GetActionResults(requestID string) ([]PLCActionResult, bool) {
+	return mb.resultReporter.APIResults(requestID)
+}
+
+// CancelWaitingCommand cancels a command waiting for a busy robot (public interface)
+func (mb *MQTTBridge) CancelWaitingCommand(robotID string, id int64) (WaitingCommand, bool) {
+	return mb.messageProcessor.CancelWaitingCommand(robotID, id)
 }
 
 // SendFactsheetRequest sends a factsheet request to a specific robot (public interface)
@@ -213,28 +411,39 @@ func (mb *MQTTBridge) SendFactsheetRequest(serialNumber string, manufacturer str
 
 // GetBridgeStatus returns overall bridge status information
 func (mb *MQTTBridge) GetBridgeStatus() BridgeStatus {
-	mqttStatus := mb.mqttClient.GetConnectionStatus()
+	mqttStatus := mb.connections.GetConnectionStatus()
 	onlineRobots := mb.robotManager.GetOnlineRobots()
 	allRobots := mb.robotManager.GetAllRobots()
 	targetRobotCount := mb.robotManager.GetTargetRobotCount()
-	reconnectCount := mb.mqttClient.GetReconnectCount()
+	reconnectCount := mb.connections.GetReconnectCount()
+
+	queuedCommands := 0
+	if mb.commandQueue != nil {
+		queuedCommands = mb.commandQueue.Len()
+	}
 
 	return BridgeStatus{
 		MQTTConnectionStatus: mqttStatus,
 		MQTTReconnectCount:   reconnectCount,
+		MQTTConnections:      mb.connections.GetConnectionInfos(),
 		TotalRobots:          len(allRobots),
 		OnlineRobots:         len(onlineRobots),
 		TargetRobotCount:     targetRobotCount,
+		QueuedCommands:       queuedCommands,
+		WaitingCommands:      mb.dispatcher.Len(),
 		LastStatusUpdate:     time.Now(),
 	}
 }
 
 // BridgeStatus represents the overall status of the bridge
 type BridgeStatus struct {
-	MQTTConnectionStatus ConnectionStatus `json:"mqttConnectionStatus"`
+	MQTTConnectionStatus ConnectionStatus `json:"mqttConnectionStatus"` // CONNECTED only if all connections are
 	MQTTReconnectCount   int32            `json:"mqttReconnectCount"`
+	MQTTConnections      []ConnectionInfo `json:"mqttConnections"`
 	TotalRobots          int              `json:"totalRobots"`
 	OnlineRobots         int              `json:"onlineRobots"`
 	TargetRobotCount     int              `json:"targetRobotCount"`
+	QueuedCommands       int              `json:"queuedCommands"`  // outbound queue (robot side broker unreachable)
+	WaitingCommands      int              `json:"waitingCommands"` // waiting for busy robots to finish their order
 	LastStatusUpdate     time.Time        `json:"lastStatusUpdate"`
 }
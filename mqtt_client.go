@@ -7,8 +7,6 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-
-	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
 // ConnectionStatus represents the connection status
@@ -39,19 +37,33 @@ func (cs ConnectionStatus) String() string {
 	}
 }
 
+// MarshalText encodes the status by name so that status JSON stays readable
+func (cs ConnectionStatus) MarshalText() ([]byte, error) {
+	return []byte(cs.String()), nil
+}
+
+// ConnectionStatusCallback is called when a broker connection changes status
+type ConnectionStatusCallback func(name string, status ConnectionStatus)
+
 // MQTTClient handles MQTT connection and basic operations
 type MQTTClient struct {
-	client   mqtt.Client
+	client   mqttTransport
 	config   *MQTTConfig
+	topics   *TopicLayout
 	handlers *MessageHandlers
 
 	// Connection status tracking
-	status      ConnectionStatus
-	statusMutex sync.RWMutex
+	status         ConnectionStatus
+	lastReason     *ConnectionReason
+	statusMutex    sync.RWMutex
+	statusCallback ConnectionStatusCallback
 
 	// Reconnection tracking
 	reconnectCount int32
 
+	// Bridge connection message header ID
+	connectionHeaderID int32
+
 	// Graceful shutdown
 	shutdownCtx    context.Context
 	shutdownCancel context.CancelFunc
@@ -59,82 +71,162 @@ type MQTTClient struct {
 
 // MessageHandlers contains all message handling functions
 type MessageHandlers struct {
-	PLCActionHandler       mqtt.MessageHandler
-	RobotConnectionHandler mqtt.MessageHandler
-	RobotStateHandler      mqtt.MessageHandler // 새로운 state 핸들러 추가
-	RobotFactsheetHandler  mqtt.MessageHandler
+	PLCActionHandler       MessageHandler
+	RobotConnectionHandler MessageHandler
+	RobotStateHandler      MessageHandler // 새로운 state 핸들러 추가
+	RobotFactsheetHandler  MessageHandler
+}
+
+// Message is a received MQTT message; the MQTT 5 properties are empty on MQTT 3.1.1
+type Message struct {
+	Connection      string // Name of the broker connection the message arrived on
+	Topic           string
+	Payload         []byte
+	ResponseTopic   string
+	CorrelationData []byte
+	UserProperties  map[string]string
+}
+
+// MessageHandler processes a received MQTT message
+type MessageHandler func(msg *Message)
+
+// PublishOptions holds per-message publish settings; everything except Retained is MQTT 5 only
+type PublishOptions struct {
+	Retained        bool
+	MessageExpiry   time.Duration // 0이면 만료 없음
+	CorrelationData []byte
+	UserProperties  map[string]string
+}
+
+// ConnectionReason is the last reason code received from an MQTT 5 broker in a CONNACK or DISCONNECT
+type ConnectionReason struct {
+	Packet     string    `json:"packet"`
+	ReasonCode byte      `json:"reasonCode"`
+	Reason     string    `json:"reason"`
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+func (cr *ConnectionReason) String() string {
+	return fmt.Sprintf("%s 0x%02X (%s)", cr.Packet, cr.ReasonCode, cr.Reason)
+}
+
+// mqttTransport is the protocol specific connection used by MQTTClient
+type mqttTransport interface {
+	Connect(timeout time.Duration) error
+	IsConnected() bool
+	Subscribe(topic string, qos byte, handler MessageHandler) error
+	Publish(topic string, qos byte, payload []byte, options PublishOptions) error
+	Disconnect()
 }
 
 // NewMQTTClient creates a new MQTT client
-func NewMQTTClient(config *MQTTConfig, handlers *MessageHandlers) *MQTTClient {
+func NewMQTTClient(config *MQTTConfig, topics *TopicLayout, handlers *MessageHandlers) (*MQTTClient, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	client := &MQTTClient{
 		config:         config,
+		topics:         topics,
 		handlers:       handlers,
 		status:         Disconnected,
 		shutdownCtx:    ctx,
 		shutdownCancel: cancel,
 	}
 
-	// Create MQTT client
-	client.client = client.createMQTTClient()
+	// Create protocol specific MQTT client
+	var err error
+	if config.ProtocolVersion == 5 {
+		var transport *mqttV5Transport
+		transport, err = newMQTTv5Transport(client)
+		client.client = transport
+	} else {
+		var transport *mqttV3Transport
+		transport, err = newMQTTv3Transport(client)
+		client.client = transport
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
 
-	return client
+	return client, nil
 }
 
-// createMQTTClient creates the underlying MQTT client
-func (mc *MQTTClient) createMQTTClient() mqtt.Client {
-	opts := mqtt.NewClientOptions()
-	opts.AddBroker(mc.config.BrokerURL)
-	opts.SetClientID(mc.config.ClientID)
-
-	if mc.config.Username != "" {
-		opts.SetUsername(mc.config.Username)
-		opts.SetPassword(mc.config.Password)
+// handleConnected marks the client connected, subscribes and announces the bridge
+func (mc *MQTTClient) handleConnected() {
+	mc.updateStatus(Connected)
+	reconnectCount := atomic.LoadInt32(&mc.reconnectCount)
+	if reconnectCount > 0 {
+		log.Printf("✅ [%s] MQTT 재연결 성공 - Broker: %s (재연결 횟수: %d)", mc.config.Name, mc.config.BrokerURL, reconnectCount)
+	} else {
+		log.Printf("✅ [%s] MQTT 클라이언트 연결됨 - Broker: %s, ClientID: %s", mc.config.Name, mc.config.BrokerURL, mc.config.ClientID)
 	}
 
-	opts.SetKeepAlive(time.Duration(mc.config.KeepAlive) * time.Second)
-	opts.SetConnectTimeout(time.Duration(mc.config.ConnectTimeout) * time.Second)
-	opts.SetAutoReconnect(true)
-	opts.SetConnectRetry(true)
-	opts.SetCleanSession(mc.config.CleanSession)
-	opts.SetMaxReconnectInterval(time.Duration(mc.config.MaxReconnectDelay) * time.Second)
-
-	// Connection handlers
-	opts.SetOnConnectHandler(func(client mqtt.Client) {
-		mc.updateStatus(Connected)
-		reconnectCount := atomic.LoadInt32(&mc.reconnectCount)
-		if reconnectCount > 0 {
-			log.Printf("✅ MQTT 재연결 성공 - Broker: %s (재연결 횟수: %d)", mc.config.BrokerURL, reconnectCount)
-		} else {
-			log.Printf("✅ MQTT 클라이언트 연결됨 - Broker: %s, ClientID: %s", mc.config.BrokerURL, mc.config.ClientID)
-		}
+	// Subscribe to all topics on (re)connection
+	mc.subscribeToTopics()
 
-		// Subscribe to all topics on (re)connection
-		mc.subscribeToTopics()
-	})
+	// Birth message: announce the bridge is ready to accept commands
+	mc.publishBridgeConnection(Online)
+}
 
-	opts.SetConnectionLostHandler(func(client mqtt.Client, err error) {
-		mc.updateStatus(ConnectionLost)
-		log.Printf("❌ MQTT 연결 끊어짐 - Error: %v", err)
-		atomic.AddInt32(&mc.reconnectCount, 1)
-	})
+// handleConnectionLost marks the connection lost and counts the reconnection
+func (mc *MQTTClient) handleConnectionLost(err error) {
+	mc.updateStatus(ConnectionLost)
+	log.Printf("❌ [%s] MQTT 연결 끊어짐 - Error: %v", mc.config.Name, err)
+	atomic.AddInt32(&mc.reconnectCount, 1)
+}
+
+// handleReconnecting marks the client as reconnecting
+func (mc *MQTTClient) handleReconnecting() {
+	mc.updateStatus(Connecting)
+	reconnectCount := atomic.LoadInt32(&mc.reconnectCount)
+	log.Printf("🔄 [%s] MQTT 재연결 시도 중... (시도 횟수: %d)", mc.config.Name, reconnectCount)
+}
 
-	opts.SetReconnectingHandler(func(client mqtt.Client, opts *mqtt.ClientOptions) {
-		mc.updateStatus(Connecting)
-		reconnectCount := atomic.LoadInt32(&mc.reconnectCount)
-		log.Printf("🔄 MQTT 재연결 시도 중... (시도 횟수: %d)", reconnectCount)
-	})
+// recordReason stores the reason code of the last CONNACK or DISCONNECT from the broker
+func (mc *MQTTClient) recordReason(packet string, reasonCode byte, reason string) {
+	if reason == "" {
+		reason = mqttReasonCodeName(reasonCode)
+	}
 
-	return mqtt.NewClient(opts)
+	mc.statusMutex.Lock()
+	defer mc.statusMutex.Unlock()
+	mc.lastReason = &ConnectionReason{
+		Packet:     packet,
+		ReasonCode: reasonCode,
+		Reason:     reason,
+		ReceivedAt: time.Now(),
+	}
 }
 
-// updateStatus updates connection status
-func (mc *MQTTClient) updateStatus(status ConnectionStatus) {
+// GetLastReason returns the last broker reason code, or nil if none was received (always nil on MQTT 3.1.1)
+func (mc *MQTTClient) GetLastReason() *ConnectionReason {
+	mc.statusMutex.RLock()
+	defer mc.statusMutex.RUnlock()
+	if mc.lastReason == nil {
+		return nil
+	}
+	reason := *mc.lastReason
+	return &reason
+}
+
+// SetStatusCallback sets the callback invoked when the connection status changes
+func (mc *MQTTClient) SetStatusCallback(callback ConnectionStatusCallback) {
 	mc.statusMutex.Lock()
 	defer mc.statusMutex.Unlock()
+	mc.statusCallback = callback
+}
+
+// updateStatus updates connection status and notifies the status callback of changes
+func (mc *MQTTClient) updateStatus(status ConnectionStatus) {
+	mc.statusMutex.Lock()
+	changed := mc.status != status
 	mc.status = status
+	callback := mc.statusCallback
+	mc.statusMutex.Unlock()
+
+	if changed && callback != nil {
+		callback(mc.config.Name, status)
+	}
 }
 
 // GetConnectionStatus returns current connection status
@@ -155,26 +247,18 @@ func (mc *MQTTClient) Connect() error {
 	connectTimeout := time.Duration(mc.config.ConnectTimeout) * time.Second
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		log.Printf("🔌 MQTT 연결 시도 중... (%d/%d) - Broker: %s",
-			attempt, maxAttempts, mc.config.BrokerURL)
+		log.Printf("🔌 [%s] MQTT 연결 시도 중... (%d/%d) - Broker: %s",
+			mc.config.Name, attempt, maxAttempts, mc.config.BrokerURL)
 
-		// Attempt connection
-		token := mc.client.Connect()
-
-		// Wait for connection with timeout
-		if token.WaitTimeout(connectTimeout) && token.Error() == nil {
-			log.Printf("✅ MQTT 연결 성공")
+		// Attempt connection and wait with timeout
+		err := mc.client.Connect(connectTimeout)
+		if err == nil {
+			log.Printf("✅ [%s] MQTT 연결 성공", mc.config.Name)
 			return nil
 		}
 
 		// Log connection error
-		if token.Error() != nil {
-			log.Printf("❌ MQTT 연결 실패 (%d/%d) - Error: %v",
-				attempt, maxAttempts, token.Error())
-		} else {
-			log.Printf("❌ MQTT 연결 타임아웃 (%d/%d) - Timeout: %v",
-				attempt, maxAttempts, connectTimeout)
-		}
+		log.Printf("❌ [%s] MQTT 연결 실패 (%d/%d) - Error: %v", mc.config.Name, attempt, maxAttempts, err)
 
 		// Wait before retry (except for last attempt)
 		if attempt < maxAttempts {
@@ -190,69 +274,66 @@ func (mc *MQTTClient) Connect() error {
 		}
 	}
 
-	return fmt.Errorf("MQTT 연결 실패 - 최대 재시도 횟수 초과 (%d번)", maxAttempts)
+	return fmt.Errorf("MQTT 연결 '%s' 실패 - 최대 재시도 횟수 초과 (%d번)", mc.config.Name, maxAttempts)
 }
 
 // subscribeToTopics subscribes to all required topics
 func (mc *MQTTClient) subscribeToTopics() {
 	if !mc.client.IsConnected() {
-		log.Printf("❌ MQTT 클라이언트가 연결되지 않아 토픽 구독 불가")
+		log.Printf("❌ [%s] MQTT 클라이언트가 연결되지 않아 토픽 구독 불가", mc.config.Name)
 		return
 	}
 
-	// Subscribe to PLC action messages
-	actionTopic := "bridge/actions"
-	token := mc.client.Subscribe(actionTopic, mc.config.QoS, mc.handlers.PLCActionHandler)
-	if token.WaitTimeout(5*time.Second) && token.Error() == nil {
-		log.Printf("✅ PLC 액션 토픽 구독 완료: %s", actionTopic)
-	} else {
-		log.Printf("❌ PLC 액션 토픽 구독 실패: %v", token.Error())
+	type subscription struct {
+		name    string
+		topic   string
+		handler MessageHandler
 	}
-
-	// Subscribe to robot connection status messages
-	connectionTopic := "meili/v2/Roboligent/+/connection"
-	token = mc.client.Subscribe(connectionTopic, mc.config.QoS, mc.handlers.RobotConnectionHandler)
-	if token.WaitTimeout(5*time.Second) && token.Error() == nil {
-		log.Printf("✅ 로봇 연결 상태 토픽 구독 완료: %s", connectionTopic)
-	} else {
-		log.Printf("❌ 로봇 연결 상태 토픽 구독 실패: %v", token.Error())
+	var subscriptions []subscription
+	if mc.config.ServesPLC {
+		subscriptions = append(subscriptions,
+			subscription{"PLC 액션", mc.topics.PLCActionTopic(), mc.handlers.PLCActionHandler})
 	}
-
-	// Subscribe to robot state messages
-	stateTopic := "meili/v2/Roboligent/+/state"
-	token = mc.client.Subscribe(stateTopic, mc.config.QoS, mc.handlers.RobotStateHandler)
-	if token.WaitTimeout(5*time.Second) && token.Error() == nil {
-		log.Printf("✅ 로봇 상태 토픽 구독 완료: %s", stateTopic)
-	} else {
-		log.Printf("❌ 로봇 상태 토픽 구독 실패: %v", token.Error())
+	if mc.config.ServesRobots {
+		subscriptions = append(subscriptions,
+			subscription{"로봇 연결 상태", mc.topics.RobotSubscription("+", topicConnection), mc.handlers.RobotConnectionHandler},
+			subscription{"로봇 상태", mc.topics.RobotSubscription("+", topicState), mc.handlers.RobotStateHandler},
+			subscription{"로봇 Factsheet", mc.topics.RobotSubscription("+", topicFactsheet), mc.handlers.RobotFactsheetHandler})
 	}
 
-	// Subscribe to robot factsheet responses
-	factsheetTopic := "meili/v2/+/+/factsheet"
-	token = mc.client.Subscribe(factsheetTopic, mc.config.QoS, mc.handlers.RobotFactsheetHandler)
-	if token.WaitTimeout(5*time.Second) && token.Error() == nil {
-		log.Printf("✅ 로봇 Factsheet 토픽 구독 완료: %s", factsheetTopic)
-	} else {
-		log.Printf("❌ 로봇 Factsheet 토픽 구독 실패: %v", token.Error())
+	for _, sub := range subscriptions {
+		handler := sub.handler
+		tagged := func(msg *Message) {
+			msg.Connection = mc.config.Name
+			handler(msg)
+		}
+		if err := mc.client.Subscribe(sub.topic, mc.config.QoS, tagged); err != nil {
+			log.Printf("❌ [%s] %s 토픽 구독 실패: %v", mc.config.Name, sub.name, err)
+			continue
+		}
+		log.Printf("✅ [%s] %s 토픽 구독 완료: %s", mc.config.Name, sub.name, sub.topic)
 	}
 }
 
 // Publish publishes a message to a topic
 func (mc *MQTTClient) Publish(topic string, payload []byte) error {
-	if !mc.client.IsConnected() {
-		return fmt.Errorf("MQTT 클라이언트가 연결되지 않음")
-	}
+	return mc.PublishWithOptions(topic, payload, PublishOptions{})
+}
 
-	// Publish with timeout check
-	token := mc.client.Publish(topic, mc.config.QoS, false, payload)
+// PublishRetained publishes a message the broker keeps as the last known value of the topic
+func (mc *MQTTClient) PublishRetained(topic string, payload []byte) error {
+	return mc.PublishWithOptions(topic, payload, PublishOptions{Retained: true})
+}
 
-	// Wait for publish completion with timeout
-	if !token.WaitTimeout(5 * time.Second) {
-		return fmt.Errorf("MQTT 발행 타임아웃")
+// PublishWithOptions publishes a message and waits for completion
+// MQTT 5 properties in the options are dropped on MQTT 3.1.1
+func (mc *MQTTClient) PublishWithOptions(topic string, payload []byte, options PublishOptions) error {
+	if !mc.client.IsConnected() {
+		return fmt.Errorf("MQTT 클라이언트가 연결되지 않음")
 	}
 
-	if token.Error() != nil {
-		return fmt.Errorf("MQTT 발행 실패: %w", token.Error())
+	if err := mc.client.Publish(topic, mc.config.QoS, payload, options); err != nil {
+		return fmt.Errorf("MQTT 발행 실패: %w", err)
 	}
 
 	return nil
@@ -260,18 +341,20 @@ func (mc *MQTTClient) Publish(topic string, payload []byte) error {
 
 // Stop gracefully disconnects the MQTT client
 func (mc *MQTTClient) Stop() {
-	log.Printf("🛑 MQTT 클라이언트 종료 중...")
+	log.Printf("🛑 [%s] MQTT 클라이언트 종료 중...", mc.config.Name)
 
 	// Signal shutdown
 	mc.shutdownCancel()
 
 	// Disconnect client
 	if mc.client.IsConnected() {
-		mc.client.Disconnect(250)
-		log.Printf("✅ MQTT 클라이언트 연결 해제됨")
+		// The broker drops the last will on a clean disconnect, so announce OFFLINE ourselves
+		mc.publishBridgeConnection(Offline)
+		mc.client.Disconnect()
+		log.Printf("✅ [%s] MQTT 클라이언트 연결 해제됨", mc.config.Name)
 	}
 
-	log.Printf("✅ MQTT 클라이언트 종료 완료")
+	log.Printf("✅ [%s] MQTT 클라이언트 종료 완료", mc.config.Name)
 }
 
 // GetReconnectCount returns the number of reconnection attempts
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConnectionStatusJSON(t *testing.T) {
+	tests := []struct {
+		status ConnectionStatus
+		want   string
+	}{
+		{Connected, "CONNECTED"},
+		{ConnectionLost, "CONNECTION_LOST"},
+		{ConnectionStatus(42), "UNKNOWN"},
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(ConnectionInfo{Status: tt.status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var info struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(data, &info); err != nil {
+			t.Fatal(err)
+		}
+		if info.Status != tt.want {
+			t.Errorf("status %d encodes as %q, want %q", int(tt.status), info.Status, tt.want)
+		}
+	}
+}
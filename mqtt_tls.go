@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsBrokerSchemes are the broker URL schemes that use TLS
+var tlsBrokerSchemes = map[string]bool{
+	"ssl":   true,
+	"tls":   true,
+	"mqtts": true,
+	"tcps":  true,
+	"wss":   true,
+}
+
+// brokerHostname returns the host of the broker URL without port or brackets
+func brokerHostname(brokerURL string) string {
+	parsed, err := url.Parse(brokerURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Hostname()
+}
+
+// isTLSBrokerURL reports whether the broker URL uses a TLS scheme
+func isTLSBrokerURL(brokerURL string) bool {
+	parsed, err := url.Parse(brokerURL)
+	if err != nil {
+		return false
+	}
+	return tlsBrokerSchemes[parsed.Scheme]
+}
+
+// hasTLSSettings reports whether any TLS file or option is configured
+func (mc *MQTTConfig) hasTLSSettings() bool {
+	return mc.TLSCAFile != "" || mc.TLSCertFile != "" || mc.TLSKeyFile != "" || mc.TLSServerName != ""
+}
+
+// tlsFileReloader reloads the CA bundle and client certificate when their files change on disk,
+// so rotated certificates are picked up on the next (re)connect without restarting the bridge
+type tlsFileReloader struct {
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverName string // name (or IP address) the broker certificate must be valid for
+
+	mutex      sync.Mutex
+	caModTime  time.Time
+	certStamp  [2]time.Time // cert and key modification times
+	rootCAs    *x509.CertPool
+	clientCert *tls.Certificate
+}
+
+// newTLSConfig builds the broker TLS config; the files are loaded once here to fail fast
+func newTLSConfig(config *MQTTConfig) (*tls.Config, error) {
+	reloader := &tlsFileReloader{
+		caFile:     config.TLSCAFile,
+		certFile:   config.TLSCertFile,
+		keyFile:    config.TLSKeyFile,
+		serverName: config.TLSServerName,
+	}
+	if reloader.serverName == "" {
+		reloader.serverName = brokerHostname(config.BrokerURL)
+	}
+	if _, err := reloader.getRootCAs(); err != nil {
+		return nil, err
+	}
+	if _, err := reloader.getClientCertificate(nil); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: config.TLSServerName,
+	}
+	if reloader.certFile != "" {
+		tlsConfig.GetClientCertificate = reloader.getClientCertificate
+	}
+	if reloader.caFile != "" {
+		// Standard verification uses a fixed RootCAs pool; verify against the reloaded pool instead
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = reloader.verifyConnection
+	}
+	return tlsConfig, nil
+}
+
+// getRootCAs returns the CA pool, reloading it if the CA file changed
+func (r *tlsFileReloader) getRootCAs() (*x509.CertPool, error) {
+	if r.caFile == "" {
+		return nil, nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	info, err := os.Stat(r.caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	if r.rootCAs != nil && info.ModTime().Equal(r.caModTime) {
+		return r.rootCAs, nil
+	}
+
+	data, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA file %s contains no PEM certificates", r.caFile)
+	}
+
+	if r.rootCAs != nil {
+		log.Printf("🔐 CA 인증서 다시 로드됨 - File: %s", r.caFile)
+	}
+	r.rootCAs = pool
+	r.caModTime = info.ModTime()
+	return pool, nil
+}
+
+// getClientCertificate returns the client certificate, reloading it if the cert or key file changed
+func (r *tlsFileReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if r.certFile == "" {
+		return &tls.Certificate{}, nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+	stamp := [2]time.Time{certInfo.ModTime(), keyInfo.ModTime()}
+	if r.clientCert != nil && stamp == r.certStamp {
+		return r.clientCert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.clientCert != nil {
+			// A rotation may replace cert and key one after the other; keep the previous pair meanwhile
+			log.Printf("⚠️  클라이언트 인증서 다시 로드 실패 - 이전 인증서 사용: %v", err)
+			return r.clientCert, nil
+		}
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	if r.clientCert != nil {
+		log.Printf("🔐 클라이언트 인증서 다시 로드됨 - File: %s", r.certFile)
+	}
+	r.clientCert = &cert
+	r.certStamp = stamp
+	return r.clientCert, nil
+}
+
+// verifyConnection verifies the broker certificate chain and host name against the current CA pool
+// The name is the configured one, not state.ServerName: no SNI is sent for an IP address broker,
+// and an empty name would skip the host check entirely
+func (r *tlsFileReloader) verifyConnection(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("broker presented no certificate")
+	}
+	if r.serverName == "" {
+		return fmt.Errorf("no broker host name to verify the certificate against")
+	}
+
+	rootCAs, err := r.getRootCAs()
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         rootCAs,
+		Intermediates: intermediates,
+		DNSName:       r.serverName,
+	})
+	return err
+}
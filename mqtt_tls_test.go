@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a throwaway certificate authority for TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue signs a leaf certificate valid for the given DNS names and IP addresses
+func (ca *testCA) issue(t *testing.T, dnsNames []string, ips []net.IP) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// writeCAFile writes the CA certificate as PEM and returns its path
+func (ca *testCA) writeCAFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTLSVerifyConnectionChecksBrokerName(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := ca.writeCAFile(t)
+
+	brokerCert := ca.issue(t, []string{"broker.local"}, []net.IP{net.ParseIP("10.0.0.5")})
+	robotCert := ca.issue(t, []string{"DEX0001"}, nil) // e.g. a robot's mTLS client certificate
+
+	tests := []struct {
+		name       string
+		brokerURL  string
+		serverName string
+		cert       *x509.Certificate
+		wantErr    bool
+	}{
+		{"IP broker with matching IP SAN", "ssl://10.0.0.5:8883", "", brokerCert, false},
+		{"IP broker with certificate of another host", "ssl://10.0.0.5:8883", "", robotCert, true},
+		{"IP broker not in SAN", "ssl://10.0.0.6:8883", "", brokerCert, true},
+		{"DNS broker", "mqtts://broker.local:8883", "", brokerCert, false},
+		{"configured server name wins", "ssl://10.0.0.6:8883", "broker.local", brokerCert, false},
+		{"configured server name mismatch", "ssl://10.0.0.5:8883", "other.local", brokerCert, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := newTLSConfig(&MQTTConfig{
+				BrokerURL:     tt.brokerURL,
+				TLSCAFile:     caFile,
+				TLSServerName: tt.serverName,
+			})
+			if err != nil {
+				t.Fatalf("newTLSConfig: %v", err)
+			}
+
+			// crypto/tls sends no SNI for IP addresses, so ServerName is empty here
+			state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{tt.cert}}
+			err = tlsConfig.VerifyConnection(state)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyConnection error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSVerifyConnectionRejectsUnknownCA(t *testing.T) {
+	trusted := newTestCA(t)
+	other := newTestCA(t)
+
+	tlsConfig, err := newTLSConfig(&MQTTConfig{
+		BrokerURL: "ssl://10.0.0.5:8883",
+		TLSCAFile: trusted.writeCAFile(t),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := other.issue(t, nil, []net.IP{net.ParseIP("10.0.0.5")})
+	if err := tlsConfig.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}); err == nil {
+		t.Fatal("certificate from an untrusted CA was accepted")
+	}
+}
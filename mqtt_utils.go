@@ -5,44 +5,188 @@ import (
 	"strings"
 )
 
-// parseRobotConnectionTopic extracts serial number from robot connection topic
-func parseRobotConnectionTopic(topic string) (string, error) {
-	// Topic format: meili/v2/Roboligent/{serial_number}/connection
-	parts := strings.Split(topic, "/")
-	if len(parts) != 5 || parts[0] != "meili" || parts[1] != "v2" || parts[2] != "Roboligent" || parts[4] != "connection" {
-		return "", fmt.Errorf("invalid connection topic format: %s", topic)
+// Robot topic template placeholders
+const (
+	placeholderInterfaceName = "{interfaceName}"
+	placeholderMajorVersion  = "{majorVersion}"
+	placeholderManufacturer  = "{manufacturer}"
+	placeholderSerialNumber  = "{serialNumber}"
+	placeholderTopic         = "{topic}"
+)
+
+// VDA5050 robot sub-topics
+const (
+	topicConnection     = "connection"
+	topicState          = "state"
+	topicFactsheet      = "factsheet"
+	topicOrders         = "orders"
+	topicInstantActions = "instantActions"
+)
+
+// TopicLayout builds, subscribes and parses robot and PLC topics from a single template
+// so that subscribe, parse and publish always use the same layout
+type TopicLayout struct {
+	segments          []string // template segments with interface name and version resolved
+	manufacturer      string
+	manufacturerIndex int // -1 if the template has no manufacturer segment
+	serialIndex       int
+	topicIndex        int
+
+	plcActionTopic   string
+	plcResultTopic   string
+	robotEventTopic  string
+	fleetStatusTopic string
+
+	bridgeConnectionTopic string
+	bridgeHeartbeatTopic  string
+}
+
+// NewTopicLayout creates a topic layout by parsing and validating the robot topic template
+func NewTopicLayout(config *TopicConfig) (*TopicLayout, error) {
+	resolved := strings.NewReplacer(
+		placeholderInterfaceName, config.InterfaceName,
+		placeholderMajorVersion, config.MajorVersion,
+	).Replace(config.RobotTopicTemplate)
+
+	layout := &TopicLayout{
+		segments:          strings.Split(resolved, "/"),
+		manufacturer:      config.Manufacturer,
+		manufacturerIndex: -1,
+		serialIndex:       -1,
+		topicIndex:        -1,
+		plcActionTopic:    config.PLCActionTopic,
+		plcResultTopic:    config.PLCResultTopic,
+		robotEventTopic:   config.RobotEventTopic,
+		fleetStatusTopic:  config.FleetStatusTopic,
+
+		bridgeConnectionTopic: config.BridgeConnectionTopic,
+		bridgeHeartbeatTopic:  config.BridgeHeartbeatTopic,
+	}
+
+	for i, segment := range layout.segments {
+		switch segment {
+		case placeholderManufacturer:
+			layout.manufacturerIndex = i
+		case placeholderSerialNumber:
+			layout.serialIndex = i
+		case placeholderTopic:
+			layout.topicIndex = i
+		case "":
+			return nil, fmt.Errorf("robot topic template contains an empty segment: %s", config.RobotTopicTemplate)
+		default:
+			if strings.ContainsAny(segment, "{}+#") {
+				return nil, fmt.Errorf("robot topic template segment '%s' must be a literal or a whole placeholder", segment)
+			}
+		}
+	}
+
+	if layout.serialIndex < 0 || layout.topicIndex < 0 {
+		return nil, fmt.Errorf("robot topic template must contain %s and %s segments: %s",
+			placeholderSerialNumber, placeholderTopic, config.RobotTopicTemplate)
 	}
-	return parts[3], nil
+	return layout, nil
 }
 
-// parseRobotStateTopic extracts serial number from robot state topic
-func parseRobotStateTopic(topic string) (string, error) {
-	// Topic format: meili/v2/Roboligent/{serial_number}/state
-	parts := strings.Split(topic, "/")
-	if len(parts) != 5 || parts[0] != "meili" || parts[1] != "v2" || parts[2] != "Roboligent" || parts[4] != "state" {
-		return "", fmt.Errorf("invalid state topic format: %s", topic)
+// Manufacturer returns the configured manufacturer used when the template has no manufacturer segment
+func (tl *TopicLayout) Manufacturer() string {
+	return tl.manufacturer
+}
+
+// HasManufacturerSegment reports whether robot topics carry the manufacturer
+func (tl *TopicLayout) HasManufacturerSegment() bool {
+	return tl.manufacturerIndex >= 0
+}
+
+// buildRobotTopic fills the template with the given values
+func (tl *TopicLayout) buildRobotTopic(manufacturer string, serialNumber string, subTopic string) string {
+	parts := make([]string, len(tl.segments))
+	copy(parts, tl.segments)
+
+	if tl.manufacturerIndex >= 0 {
+		parts[tl.manufacturerIndex] = manufacturer
 	}
-	return parts[3], nil
+	parts[tl.serialIndex] = serialNumber
+	parts[tl.topicIndex] = subTopic
+	return strings.Join(parts, "/")
 }
 
-// parseRobotFactsheetTopic extracts serial number and manufacturer from factsheet topic
-func parseRobotFactsheetTopic(topic string) (string, string, error) {
-	// Topic format: meili/v2/{manufacturer}/{serial_number}/factsheet
+// RobotSubscription returns a subscription filter for a robot sub-topic of all robots of a manufacturer
+// Use "+" as manufacturer to match every manufacturer
+func (tl *TopicLayout) RobotSubscription(manufacturer string, subTopic string) string {
+	return tl.buildRobotTopic(manufacturer, "+", subTopic)
+}
+
+// ParseRobotTopic extracts serial number and manufacturer from a robot topic of the expected sub-topic
+func (tl *TopicLayout) ParseRobotTopic(topic string, subTopic string) (string, string, error) {
 	parts := strings.Split(topic, "/")
-	if len(parts) != 5 || parts[0] != "meili" || parts[1] != "v2" || parts[4] != "factsheet" {
-		return "", "", fmt.Errorf("invalid factsheet topic format: %s", topic)
+	if len(parts) != len(tl.segments) {
+		return "", "", fmt.Errorf("invalid %s topic format: %s", subTopic, topic)
+	}
+
+	manufacturer := tl.manufacturer
+	for i, segment := range tl.segments {
+		switch i {
+		case tl.serialIndex:
+			if parts[i] == "" {
+				return "", "", fmt.Errorf("invalid %s topic format: %s", subTopic, topic)
+			}
+		case tl.manufacturerIndex:
+			manufacturer = parts[i]
+		case tl.topicIndex:
+			if parts[i] != subTopic {
+				return "", "", fmt.Errorf("invalid %s topic format: %s", subTopic, topic)
+			}
+		default:
+			if parts[i] != segment {
+				return "", "", fmt.Errorf("invalid %s topic format: %s", subTopic, topic)
+			}
+		}
 	}
-	manufacturer := parts[2]
-	serialNumber := parts[3]
-	return serialNumber, manufacturer, nil
+	return parts[tl.serialIndex], manufacturer, nil
+}
+
+// RobotInstantActionsTopic builds a robot instant action topic for a given manufacturer and serial number
+func (tl *TopicLayout) RobotInstantActionsTopic(manufacturer string, serialNumber string) string {
+	return tl.buildRobotTopic(manufacturer, serialNumber, topicInstantActions)
+}
+
+// RobotOrderTopic builds a robot order topic for a given manufacturer and serial number
+func (tl *TopicLayout) RobotOrderTopic(manufacturer string, serialNumber string) string {
+	return tl.buildRobotTopic(manufacturer, serialNumber, topicOrders)
+}
+
+// PLCActionTopic returns the topic the PLC publishes commands to
+func (tl *TopicLayout) PLCActionTopic() string {
+	return tl.plcActionTopic
+}
+
+// PLCResultTopic builds the PLC result topic, substituting {serial} when present
+func (tl *TopicLayout) PLCResultTopic(serialNumber string) string {
+	if serialNumber == "" {
+		serialNumber = "unknown"
+	}
+	return strings.ReplaceAll(tl.plcResultTopic, "{serial}", serialNumber)
+}
+
+// RobotEventTopic builds the robot event topic, substituting {serial} and {manufacturer} when present
+func (tl *TopicLayout) RobotEventTopic(manufacturer string, serialNumber string) string {
+	return strings.NewReplacer(
+		"{serial}", serialNumber,
+		"{manufacturer}", manufacturer,
+	).Replace(tl.robotEventTopic)
+}
+
+// FleetStatusTopic returns the retained fleet status topic
+func (tl *TopicLayout) FleetStatusTopic() string {
+	return tl.fleetStatusTopic
 }
 
-// buildRobotActionTopic builds a robot instant action topic for a given serial number
-func buildRobotActionTopic(serialNumber string) string {
-	return fmt.Sprintf("meili/v2/Roboligent/%s/instantActions", serialNumber)
+// BridgeConnectionTopic returns the retained bridge connection topic used for birth and last will
+func (tl *TopicLayout) BridgeConnectionTopic() string {
+	return tl.bridgeConnectionTopic
 }
 
-// buildRobotOrderTopic builds a robot order topic for a given serial number
-func buildRobotOrderTopic(serialNumber string) string {
-	return fmt.Sprintf("meili/v2/Roboligent/%s/orders", serialNumber)
+// BridgeHeartbeatTopic returns the bridge heartbeat topic
+func (tl *TopicLayout) BridgeHeartbeatTopic() string {
+	return tl.bridgeHeartbeatTopic
 }
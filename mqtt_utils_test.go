@@ -0,0 +1,113 @@
+package main
+
+import "testing"
+
+// newTestTopicConfig returns a topic config with the given robot topic template and default PLC topics
+func newTestTopicConfig(template string) *TopicConfig {
+	return &TopicConfig{
+		InterfaceName:      "uagv",
+		MajorVersion:       "v2",
+		Manufacturer:       "Roboligent",
+		RobotTopicTemplate: template,
+		PLCActionTopic:     "bridge/actions",
+		PLCResultTopic:     "bridge/actions/result/{serial}",
+	}
+}
+
+func TestNewTopicLayout(t *testing.T) {
+	tests := []struct {
+		name           string
+		template       string
+		wantErr        bool
+		wantOrderTopic string // order topic of Roboligent/DEX0001
+		wantSubscribe  string // state subscription for all manufacturers
+	}{
+		{"default layout", "{interfaceName}/{majorVersion}/{manufacturer}/{serialNumber}/{topic}", false,
+			"uagv/v2/Roboligent/DEX0001/orders", "uagv/v2/+/+/state"},
+		{"without manufacturer", "{interfaceName}/{majorVersion}/{serialNumber}/{topic}", false,
+			"uagv/v2/DEX0001/orders", "uagv/v2/+/state"},
+		{"literal prefix", "factory/{interfaceName}/{serialNumber}/{topic}", false,
+			"factory/uagv/DEX0001/orders", "factory/uagv/+/state"},
+
+		{"missing serial number", "{interfaceName}/{majorVersion}/{manufacturer}/{topic}", true, "", ""},
+		{"missing topic", "{interfaceName}/{majorVersion}/{serialNumber}", true, "", ""},
+		{"empty segment", "{interfaceName}//{serialNumber}/{topic}", true, "", ""},
+		{"partial placeholder", "{interfaceName}/robot-{serialNumber}/{topic}", true, "", ""},
+		{"wildcard segment", "{interfaceName}/+/{serialNumber}/{topic}", true, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := NewTopicLayout(newTestTopicConfig(tt.template))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTopicLayout(%q) error = %v, wantErr %t", tt.template, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := layout.RobotOrderTopic("Roboligent", "DEX0001"); got != tt.wantOrderTopic {
+				t.Fatalf("RobotOrderTopic = %q, want %q", got, tt.wantOrderTopic)
+			}
+			if got := layout.RobotSubscription("+", topicState); got != tt.wantSubscribe {
+				t.Fatalf("RobotSubscription = %q, want %q", got, tt.wantSubscribe)
+			}
+		})
+	}
+}
+
+func TestParseRobotTopic(t *testing.T) {
+	withManufacturer, err := NewTopicLayout(newTestTopicConfig("{interfaceName}/{majorVersion}/{manufacturer}/{serialNumber}/{topic}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutManufacturer, err := NewTopicLayout(newTestTopicConfig("{interfaceName}/{majorVersion}/{serialNumber}/{topic}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		layout           *TopicLayout
+		topic            string
+		subTopic         string
+		wantSerial       string
+		wantManufacturer string
+		wantErr          bool
+	}{
+		{"manufacturer from topic", withManufacturer, "uagv/v2/Acme/DEX0001/state", topicState, "DEX0001", "Acme", false},
+		{"configured manufacturer", withoutManufacturer, "uagv/v2/DEX0001/connection", topicConnection, "DEX0001", "Roboligent", false},
+
+		{"other sub-topic", withManufacturer, "uagv/v2/Acme/DEX0001/factsheet", topicState, "", "", true},
+		{"other interface", withManufacturer, "meili/v2/Acme/DEX0001/state", topicState, "", "", true},
+		{"other version", withoutManufacturer, "uagv/v1/DEX0001/state", topicState, "", "", true},
+		{"too few segments", withManufacturer, "uagv/v2/DEX0001/state", topicState, "", "", true},
+		{"too many segments", withoutManufacturer, "uagv/v2/Acme/DEX0001/state", topicState, "", "", true},
+		{"empty serial", withManufacturer, "uagv/v2/Acme//state", topicState, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serial, manufacturer, err := tt.layout.ParseRobotTopic(tt.topic, tt.subTopic)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRobotTopic(%q) error = %v, wantErr %t", tt.topic, err, tt.wantErr)
+			}
+			if serial != tt.wantSerial || manufacturer != tt.wantManufacturer {
+				t.Fatalf("ParseRobotTopic(%q) = (%q, %q), want (%q, %q)",
+					tt.topic, serial, manufacturer, tt.wantSerial, tt.wantManufacturer)
+			}
+		})
+	}
+}
+
+func TestParseRobotTopicRoundTrip(t *testing.T) {
+	layout, err := NewTopicLayout(newTestTopicConfig("{interfaceName}/{majorVersion}/{manufacturer}/{serialNumber}/{topic}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	topic := layout.RobotInstantActionsTopic("Acme", "DEX0003")
+	serial, manufacturer, err := layout.ParseRobotTopic(topic, topicInstantActions)
+	if err != nil || serial != "DEX0003" || manufacturer != "Acme" {
+		t.Fatalf("ParseRobotTopic(%q) = (%q, %q, %v), want (DEX0003, Acme, nil)", topic, serial, manufacturer, err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttV3Transport connects to the broker with MQTT 3.1.1 (paho.mqtt.golang)
+type mqttV3Transport struct {
+	client mqtt.Client
+}
+
+// newMQTTv3Transport creates the MQTT 3.1.1 client reporting connection events to mc
+func newMQTTv3Transport(mc *MQTTClient) (*mqttV3Transport, error) {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(mc.config.BrokerURL)
+	opts.SetClientID(mc.config.ClientID)
+
+	if mc.config.Username != "" {
+		opts.SetUsername(mc.config.Username)
+		opts.SetPassword(mc.config.Password)
+	}
+
+	if isTLSBrokerURL(mc.config.BrokerURL) {
+		tlsConfig, err := newTLSConfig(mc.config)
+		if err != nil {
+			return nil, fmt.Errorf("TLS 설정 생성 실패: %w", err)
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	opts.SetKeepAlive(time.Duration(mc.config.KeepAlive) * time.Second)
+	opts.SetConnectTimeout(time.Duration(mc.config.ConnectTimeout) * time.Second)
+	opts.SetAutoReconnect(true)
+	opts.SetConnectRetry(true)
+	opts.SetCleanSession(mc.config.CleanSession)
+	opts.SetMaxReconnectInterval(time.Duration(mc.config.MaxReconnectDelay) * time.Second)
+
+	// Last will: the broker marks the bridge OFFLINE if the connection drops without a disconnect
+	if willPayload, err := mc.bridgeConnectionPayload(Offline); err == nil {
+		opts.SetWill(mc.topics.BridgeConnectionTopic(), string(willPayload), mc.config.QoS, true)
+	} else {
+		log.Printf("❌ LWT 메시지 생성 실패: %v", err)
+	}
+
+	// Connection handlers
+	opts.SetOnConnectHandler(func(client mqtt.Client) {
+		mc.handleConnected()
+	})
+
+	opts.SetConnectionLostHandler(func(client mqtt.Client, err error) {
+		mc.handleConnectionLost(err)
+	})
+
+	opts.SetReconnectingHandler(func(client mqtt.Client, opts *mqtt.ClientOptions) {
+		mc.handleReconnecting()
+	})
+
+	return &mqttV3Transport{client: mqtt.NewClient(opts)}, nil
+}
+
+// Connect starts the connection and waits for the first CONNACK
+func (t *mqttV3Transport) Connect(timeout time.Duration) error {
+	token := t.client.Connect()
+	if !token.WaitTimeout(timeout) {
+		return fmt.Errorf("연결 타임아웃 (%v)", timeout)
+	}
+	return token.Error()
+}
+
+// IsConnected reports whether the client is connected
+func (t *mqttV3Transport) IsConnected() bool {
+	return t.client.IsConnected()
+}
+
+// Subscribe subscribes to a topic filter and waits for the SUBACK
+func (t *mqttV3Transport) Subscribe(topic string, qos byte, handler MessageHandler) error {
+	token := t.client.Subscribe(topic, qos, func(client mqtt.Client, msg mqtt.Message) {
+		handler(&Message{Topic: msg.Topic(), Payload: msg.Payload()})
+	})
+	if !token.WaitTimeout(5 * time.Second) {
+		return fmt.Errorf("구독 타임아웃")
+	}
+	return token.Error()
+}
+
+// Publish publishes a message and waits for completion; MQTT 5 options are ignored
+func (t *mqttV3Transport) Publish(topic string, qos byte, payload []byte, options PublishOptions) error {
+	token := t.client.Publish(topic, qos, options.Retained, payload)
+
+	// Wait for publish completion with timeout
+	if !token.WaitTimeout(5 * time.Second) {
+		return fmt.Errorf("발행 타임아웃")
+	}
+	return token.Error()
+}
+
+// Disconnect closes the connection after in-flight work has had time to complete
+func (t *mqttV3Transport) Disconnect() {
+	t.client.Disconnect(250)
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// mqttReasonCodeNames are the MQTT 5 reason codes a broker sends in CONNACK and DISCONNECT
+var mqttReasonCodeNames = map[byte]string{
+	0x00: "Success",
+	0x04: "Disconnect with Will Message",
+	0x80: "Unspecified error",
+	0x81: "Malformed Packet",
+	0x82: "Protocol Error",
+	0x83: "Implementation specific error",
+	0x84: "Unsupported Protocol Version",
+	0x85: "Client Identifier not valid",
+	0x86: "Bad User Name or Password",
+	0x87: "Not authorized",
+	0x88: "Server unavailable",
+	0x89: "Server busy",
+	0x8A: "Banned",
+	0x8B: "Server shutting down",
+	0x8C: "Bad authentication method",
+	0x8D: "Keep Alive timeout",
+	0x8E: "Session taken over",
+	0x90: "Topic Name invalid",
+	0x93: "Receive Maximum exceeded",
+	0x95: "Packet too large",
+	0x96: "Message rate too high",
+	0x97: "Quota exceeded",
+	0x98: "Administrative action",
+	0x99: "Payload format invalid",
+	0x9A: "Retain not supported",
+	0x9B: "QoS not supported",
+	0x9C: "Use another server",
+	0x9D: "Server moved",
+	0x9F: "Connection rate exceeded",
+}
+
+// mqttReasonCodeName returns the name of an MQTT 5 reason code
+func mqttReasonCodeName(reasonCode byte) string {
+	if name, exists := mqttReasonCodeNames[reasonCode]; exists {
+		return name
+	}
+	return "Unknown"
+}
+
+// mqttV5Subscription routes received messages matching a topic filter to a handler
+type mqttV5Subscription struct {
+	filter  string
+	handler MessageHandler
+}
+
+// mqttV5Transport connects to the broker with MQTT 5 (paho.golang autopaho)
+// autopaho reconnects on its own once the first connection attempt has started
+type mqttV5Transport struct {
+	mc        *MQTTClient
+	config    autopaho.ClientConfig
+	manager   *autopaho.ConnectionManager
+	connected atomic.Bool
+
+	lastConnectError error
+	subscriptions    []mqttV5Subscription
+	mutex            sync.Mutex
+}
+
+// newMQTTv5Transport creates the MQTT 5 client configuration reporting connection events to mc
+func newMQTTv5Transport(mc *MQTTClient) (*mqttV5Transport, error) {
+	t := &mqttV5Transport{mc: mc}
+
+	// The broker URL was validated at config load
+	brokerURL, _ := url.Parse(mc.config.BrokerURL)
+
+	t.config = autopaho.ClientConfig{
+		ServerUrls:                    []*url.URL{brokerURL},
+		KeepAlive:                     uint16(mc.config.KeepAlive),
+		CleanStartOnInitialConnection: mc.config.CleanSession,
+		ConnectTimeout:                time.Duration(mc.config.ConnectTimeout) * time.Second,
+		ReconnectBackoff:              reconnectBackoff(mc.config),
+		ConnectUsername:               mc.config.Username,
+		OnConnectionUp:                t.onConnectionUp,
+		OnConnectError:                t.onConnectError,
+		ClientConfig: paho.ClientConfig{
+			ClientID:           mc.config.ClientID,
+			OnPublishReceived:  []func(paho.PublishReceived) (bool, error){t.onPublishReceived},
+			OnServerDisconnect: t.onServerDisconnect,
+			OnClientError:      t.onClientError,
+		},
+	}
+	if mc.config.Password != "" {
+		t.config.ConnectPassword = []byte(mc.config.Password)
+	}
+
+	// A persistent session survives disconnects like MQTT 3.1.1 clean session false
+	if !mc.config.CleanSession {
+		t.config.SessionExpiryInterval = math.MaxUint32
+	}
+
+	if isTLSBrokerURL(mc.config.BrokerURL) {
+		tlsConfig, err := newTLSConfig(mc.config)
+		if err != nil {
+			return nil, fmt.Errorf("TLS 설정 생성 실패: %w", err)
+		}
+		t.config.TlsCfg = tlsConfig
+	}
+
+	// Last will: the broker marks the bridge OFFLINE if the connection drops without a disconnect
+	if willPayload, err := mc.bridgeConnectionPayload(Offline); err == nil {
+		t.config.WillMessage = &paho.WillMessage{
+			Topic:   mc.topics.BridgeConnectionTopic(),
+			Payload: willPayload,
+			QoS:     mc.config.QoS,
+			Retain:  true,
+		}
+	} else {
+		log.Printf("❌ LWT 메시지 생성 실패: %v", err)
+	}
+
+	return t, nil
+}
+
+// reconnectBackoff doubles the reconnect delay on every failed attempt up to the maximum delay
+func reconnectBackoff(config *MQTTConfig) autopaho.Backoff {
+	minDelay := time.Duration(config.ReconnectDelay) * time.Second
+	maxDelay := time.Duration(config.MaxReconnectDelay) * time.Second
+	return func(attempt int) time.Duration {
+		if attempt <= 0 {
+			return 0
+		}
+		delay := minDelay
+		for i := 1; i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		return min(delay, maxDelay)
+	}
+}
+
+// Connect starts the connection manager on the first call and waits for a connection
+func (t *mqttV5Transport) Connect(timeout time.Duration) error {
+	t.mutex.Lock()
+	if t.manager == nil {
+		// Not tied to the shutdown context so that Stop can still publish OFFLINE before disconnecting
+		manager, err := autopaho.NewConnection(context.Background(), t.config)
+		if err != nil {
+			t.mutex.Unlock()
+			return err
+		}
+		t.manager = manager
+	}
+	manager := t.manager
+	t.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(t.mc.shutdownCtx, timeout)
+	defer cancel()
+	if err := manager.AwaitConnection(ctx); err != nil {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		if t.lastConnectError != nil {
+			return t.lastConnectError
+		}
+		return fmt.Errorf("연결 타임아웃 (%v)", timeout)
+	}
+	return nil
+}
+
+// IsConnected reports whether the client is connected
+func (t *mqttV5Transport) IsConnected() bool {
+	return t.connected.Load()
+}
+
+// Subscribe subscribes to a topic filter, waits for the SUBACK and routes matching messages to the handler
+func (t *mqttV5Transport) Subscribe(topic string, qos byte, handler MessageHandler) error {
+	t.mutex.Lock()
+	manager := t.manager
+	replaced := false
+	for i := range t.subscriptions {
+		if t.subscriptions[i].filter == topic {
+			t.subscriptions[i].handler = handler
+			replaced = true
+		}
+	}
+	if !replaced {
+		t.subscriptions = append(t.subscriptions, mqttV5Subscription{filter: topic, handler: handler})
+	}
+	t.mutex.Unlock()
+
+	if manager == nil {
+		return fmt.Errorf("MQTT 클라이언트가 연결되지 않음")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	suback, err := manager.Subscribe(ctx, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{{Topic: topic, QoS: qos}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(suback.Reasons) > 0 && suback.Reasons[0] >= 0x80 {
+		return fmt.Errorf("SUBACK 0x%02X (%s)", suback.Reasons[0], mqttReasonCodeName(suback.Reasons[0]))
+	}
+	return nil
+}
+
+// Publish publishes a message with its MQTT 5 properties and waits for completion
+func (t *mqttV5Transport) Publish(topic string, qos byte, payload []byte, options PublishOptions) error {
+	t.mutex.Lock()
+	manager := t.manager
+	t.mutex.Unlock()
+	if manager == nil {
+		return fmt.Errorf("MQTT 클라이언트가 연결되지 않음")
+	}
+
+	properties := &paho.PublishProperties{
+		CorrelationData: options.CorrelationData,
+	}
+	if options.MessageExpiry > 0 {
+		expiry := uint32(max(options.MessageExpiry/time.Second, 1))
+		properties.MessageExpiry = &expiry
+	}
+	for key, value := range options.UserProperties {
+		properties.User.Add(key, value)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := manager.Publish(ctx, &paho.Publish{
+		Topic:      topic,
+		QoS:        qos,
+		Retain:     options.Retained,
+		Payload:    payload,
+		Properties: properties,
+	})
+	return err
+}
+
+// Disconnect sends a DISCONNECT and stops reconnecting
+func (t *mqttV5Transport) Disconnect() {
+	t.mutex.Lock()
+	manager := t.manager
+	t.mutex.Unlock()
+	if manager == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := manager.Disconnect(ctx); err != nil {
+		log.Printf("⚠️  MQTT 연결 해제 대기 실패: %v", err)
+	}
+	t.connected.Store(false)
+}
+
+// onConnectionUp records the CONNACK and runs the common connect handling
+func (t *mqttV5Transport) onConnectionUp(manager *autopaho.ConnectionManager, connack *paho.Connack) {
+	reason := ""
+	if connack.Properties != nil {
+		reason = connack.Properties.ReasonString
+	}
+	t.mc.recordReason("CONNACK", connack.ReasonCode, reason)
+
+	t.mutex.Lock()
+	t.lastConnectError = nil
+	t.mutex.Unlock()
+
+	t.connected.Store(true)
+	t.mc.handleConnected()
+}
+
+// onConnectError records why a connection attempt failed; autopaho retries with the reconnect backoff
+func (t *mqttV5Transport) onConnectError(err error) {
+	var connackErr *autopaho.ConnackError
+	if errors.As(err, &connackErr) {
+		t.mc.recordReason("CONNACK", connackErr.ReasonCode, connackErr.Reason)
+		err = fmt.Errorf("broker rejected connection: %s", t.mc.GetLastReason())
+	}
+
+	t.mutex.Lock()
+	t.lastConnectError = err
+	t.mutex.Unlock()
+
+	t.mc.updateStatus(ConnectionFailed)
+	log.Printf("❌ [%s] MQTT 연결 시도 실패 - Error: %v", t.mc.config.Name, err)
+}
+
+// onServerDisconnect records the DISCONNECT reason sent by the broker
+func (t *mqttV5Transport) onServerDisconnect(disconnect *paho.Disconnect) {
+	reason := ""
+	if disconnect.Properties != nil {
+		reason = disconnect.Properties.ReasonString
+	}
+	t.mc.recordReason("DISCONNECT", disconnect.ReasonCode, reason)
+
+	t.connected.Store(false)
+	t.mc.handleConnectionLost(fmt.Errorf("broker disconnected: %s", t.mc.GetLastReason()))
+}
+
+// onClientError handles a connection lost without a DISCONNECT from the broker
+func (t *mqttV5Transport) onClientError(err error) {
+	t.connected.Store(false)
+	t.mc.handleConnectionLost(err)
+}
+
+// onPublishReceived routes a received message to the handlers of all matching subscriptions
+func (t *mqttV5Transport) onPublishReceived(received paho.PublishReceived) (bool, error) {
+	packet := received.Packet
+	msg := &Message{
+		Topic:   packet.Topic,
+		Payload: packet.Payload,
+	}
+	if packet.Properties != nil {
+		msg.ResponseTopic = packet.Properties.ResponseTopic
+		msg.CorrelationData = packet.Properties.CorrelationData
+		if len(packet.Properties.User) > 0 {
+			msg.UserProperties = make(map[string]string, len(packet.Properties.User))
+			for _, property := range packet.Properties.User {
+				msg.UserProperties[property.Key] = property.Value
+			}
+		}
+	}
+
+	t.mutex.Lock()
+	var handlers []MessageHandler
+	for _, subscription := range t.subscriptions {
+		if topicMatchesFilter(subscription.filter, msg.Topic) {
+			handlers = append(handlers, subscription.handler)
+		}
+	}
+	t.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(msg)
+	}
+	return len(handlers) > 0, nil
+}
+
+// topicMatchesFilter reports whether a topic name matches a subscription filter with + and # wildcards
+func topicMatchesFilter(filter string, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
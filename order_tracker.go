@@ -0,0 +1,424 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// orderAcceptTimeout is how long a robot may keep reporting another order before a new one is considered lost
+	orderAcceptTimeout = 30 * time.Second
+
+	// maxFinishedOrdersPerRobot bounds the number of completed orders kept per robot
+	maxFinishedOrdersPerRobot = 50
+
+	// Node and edge progress states
+	elementPending   = "PENDING"
+	elementTraversed = "TRAVERSED"
+)
+
+// OrderStatusCallback is a function type for handling order lifecycle changes
+type OrderStatusCallback func(order TrackedOrder, oldStatus, newStatus OrderLifecycleStatus)
+
+// OrderTracker records every order issued by the bridge and follows it through robot state messages
+type OrderTracker struct {
+	orders              map[string]*TrackedOrder // orderID -> tracked order
+	robotOrders         map[string][]string      // robot ID -> orderIDs in issue order
+	foreignOrders       map[string]string        // robot ID -> last foreign orderID seen
+	mutex               sync.RWMutex
+	orderStatusCallback OrderStatusCallback
+}
+
+// NewOrderTracker creates a new order tracker
+func NewOrderTracker() *OrderTracker {
+	return &OrderTracker{
+		orders:        make(map[string]*TrackedOrder),
+		robotOrders:   make(map[string][]string),
+		foreignOrders: make(map[string]string),
+	}
+}
+
+// SetOrderStatusCallback sets the callback function for order lifecycle changes
+func (ot *OrderTracker) SetOrderStatusCallback(callback OrderStatusCallback) {
+	ot.mutex.Lock()
+	defer ot.mutex.Unlock()
+	ot.orderStatusCallback = callback
+}
+
+// TrackOrder starts tracking an order published to a robot
+// An order update (same orderID) extends the already tracked order instead
+func (ot *OrderTracker) TrackOrder(command string, orderMsg *OrderMessage) {
+	robotID := makeRobotID(orderMsg.Manufacturer, orderMsg.SerialNumber)
+	now := time.Now()
+
+	ot.mutex.Lock()
+	defer ot.mutex.Unlock()
+
+	if order, exists := ot.orders[orderMsg.OrderID]; exists {
+		order.OrderUpdateID = orderMsg.OrderUpdateID
+		order.Updates = append(order.Updates, command)
+		addedNodes, addedActions := appendOrderElements(order, orderMsg, now)
+
+		log.Printf("🧾 주문 업데이트 추적 - Robot: %s, OrderID: %s, OrderUpdateID: %d, 추가 Nodes: %d, 추가 Actions: %d",
+			robotID, order.OrderID, order.OrderUpdateID, addedNodes, addedActions)
+		return
+	}
+
+	order := &TrackedOrder{
+		OrderID:       orderMsg.OrderID,
+		OrderUpdateID: orderMsg.OrderUpdateID,
+		SerialNumber:  orderMsg.SerialNumber,
+		Manufacturer:  orderMsg.Manufacturer,
+		Command:       command,
+		Status:        OrderWaiting,
+		IssuedAt:      now,
+		Transitions:   []StatusTransition{{Status: string(OrderWaiting), Timestamp: now}},
+	}
+	appendOrderElements(order, orderMsg, now)
+
+	ot.orders[order.OrderID] = order
+	ot.robotOrders[robotID] = append(ot.robotOrders[robotID], order.OrderID)
+	ot.pruneFinishedOrders(robotID)
+
+	log.Printf("🧾 주문 추적 시작 - Robot: %s, OrderID: %s, Nodes: %d, Actions: %d",
+		robotID, order.OrderID, len(order.Nodes), len(order.Actions))
+}
+
+// appendOrderElements adds the nodes, edges and actions of an order message to a tracked order
+// The stitching node of an order update is already tracked and is skipped
+func appendOrderElements(order *TrackedOrder, orderMsg *OrderMessage, now time.Time) (int, int) {
+	trackedNodes := make(map[string]bool, len(order.Nodes))
+	for _, node := range order.Nodes {
+		trackedNodes[fmt.Sprintf("%s/%d", node.NodeID, node.SequenceID)] = true
+	}
+
+	addedNodes, addedActions := 0, 0
+	for _, node := range orderMsg.Nodes {
+		if trackedNodes[fmt.Sprintf("%s/%d", node.NodeID, node.SequenceID)] {
+			continue
+		}
+		order.Nodes = append(order.Nodes, TrackedNode{
+			NodeID:        node.NodeID,
+			SequenceID:    node.SequenceID,
+			OrderUpdateID: orderMsg.OrderUpdateID,
+			Status:        elementPending,
+			Transitions:   []StatusTransition{{Status: elementPending, Timestamp: now}},
+		})
+		addedNodes++
+		for _, action := range node.Actions {
+			order.Actions = append(order.Actions, newTrackedAction(action, now))
+			addedActions++
+		}
+	}
+	for _, edge := range orderMsg.Edges {
+		order.Edges = append(order.Edges, TrackedEdge{
+			EdgeID:        edge.EdgeID,
+			SequenceID:    edge.SequenceID,
+			OrderUpdateID: orderMsg.OrderUpdateID,
+			Status:        elementPending,
+			Transitions:   []StatusTransition{{Status: elementPending, Timestamp: now}},
+		})
+		for _, action := range edge.Actions {
+			order.Actions = append(order.Actions, newTrackedAction(action, now))
+			addedActions++
+		}
+	}
+
+	// All nodes are released, so the last node ends the base
+	if len(orderMsg.Nodes) > 0 {
+		order.stitchNode = orderMsg.Nodes[len(orderMsg.Nodes)-1]
+	}
+	return addedNodes, addedActions
+}
+
+// GetExtendableOrder returns the robot's active order that an order update can be stitched onto
+// The robot must have accepted the order and must not have passed the last base node
+func (ot *OrderTracker) GetExtendableOrder(robotID string) (*TrackedOrder, error) {
+	ot.mutex.RLock()
+	defer ot.mutex.RUnlock()
+
+	orderIDs := ot.robotOrders[robotID]
+	for i := len(orderIDs) - 1; i >= 0; i-- {
+		order := ot.orders[orderIDs[i]]
+		if order == nil || order.Status.IsTerminal() {
+			continue
+		}
+
+		if order.Status == OrderWaiting {
+			return nil, fmt.Errorf("order %s has not been accepted by the robot yet", order.OrderID)
+		}
+		if order.LastNodeSequenceID > order.stitchNode.SequenceID {
+			return nil, fmt.Errorf("robot reports lastNodeSequenceId %d beyond base end %d of order %s",
+				order.LastNodeSequenceID, order.stitchNode.SequenceID, order.OrderID)
+		}
+
+		orderCopy := cloneTrackedOrder(order)
+		return &orderCopy, nil
+	}
+	return nil, fmt.Errorf("robot %s has no active order to update", robotID)
+}
+
+// newTrackedAction creates a tracked action in WAITING state
+func newTrackedAction(action Action, now time.Time) TrackedAction {
+	return TrackedAction{
+		ActionID:    action.ActionID,
+		ActionType:  action.ActionType,
+		Status:      "WAITING",
+		Transitions: []StatusTransition{{Status: "WAITING", Timestamp: now}},
+	}
+}
+
+// HandleRobotState updates tracked orders of a robot from its state message
+func (ot *OrderTracker) HandleRobotState(stateMsg *RobotStateMessage) {
+	type statusChange struct {
+		order     TrackedOrder
+		oldStatus OrderLifecycleStatus
+	}
+
+	ot.mutex.Lock()
+	robotID := makeRobotID(stateMsg.Manufacturer, stateMsg.SerialNumber)
+	now := time.Now()
+	var changes []statusChange
+
+	// Detect orders the bridge did not issue
+	if stateMsg.OrderID != "" {
+		if _, tracked := ot.orders[stateMsg.OrderID]; !tracked && ot.foreignOrders[robotID] != stateMsg.OrderID {
+			ot.foreignOrders[robotID] = stateMsg.OrderID
+			log.Printf("⚠️  외부 주문 감지 - Robot: %s, OrderID: %s", robotID, stateMsg.OrderID)
+		}
+	}
+
+	for _, orderID := range ot.robotOrders[robotID] {
+		order := ot.orders[orderID]
+		if order == nil || order.Status.IsTerminal() {
+			continue
+		}
+
+		oldStatus := order.Status
+		if stateMsg.OrderID == order.OrderID {
+			ot.applyOrderState(order, stateMsg, now)
+		} else {
+			ot.applyMissingOrder(order, stateMsg, now)
+		}
+
+		if order.Status != oldStatus {
+			changes = append(changes, statusChange{order: cloneTrackedOrder(order), oldStatus: oldStatus})
+		}
+	}
+	callback := ot.orderStatusCallback
+	ot.mutex.Unlock()
+
+	// Notify outside the lock
+	for _, change := range changes {
+		log.Printf("🧾 주문 상태 변경 - Robot: %s, OrderID: %s, %s -> %s",
+			robotID, change.order.OrderID, change.oldStatus, change.order.Status)
+		if callback != nil {
+			callback(change.order, change.oldStatus, change.order.Status)
+		}
+	}
+}
+
+// applyOrderState updates an order that the robot currently reports as its own
+func (ot *OrderTracker) applyOrderState(order *TrackedOrder, stateMsg *RobotStateMessage, now time.Time) {
+	order.LastNodeSequenceID = stateMsg.LastNodeSeqID
+
+	// Node and edge progress: anything no longer reported has been traversed.
+	// The first node is the robot's current position and does not indicate progress.
+	// Elements of an order update the robot has not picked up yet are not judged.
+	pendingNodes := make(map[string]bool, len(stateMsg.NodeStates))
+	for _, nodeState := range stateMsg.NodeStates {
+		pendingNodes[nodeState.NodeID] = true
+	}
+	traversed := false
+	for i := range order.Nodes {
+		node := &order.Nodes[i]
+		if node.Status == elementPending && node.OrderUpdateID <= stateMsg.OrderUpdateID && !pendingNodes[node.NodeID] {
+			node.Status = elementTraversed
+			node.Transitions = append(node.Transitions, StatusTransition{Status: elementTraversed, Timestamp: now})
+		}
+		if node.Status == elementTraversed && i > 0 {
+			traversed = true
+		}
+	}
+
+	pendingEdges := make(map[string]bool, len(stateMsg.EdgeStates))
+	for _, edgeState := range stateMsg.EdgeStates {
+		pendingEdges[edgeState.EdgeID] = true
+	}
+	for i := range order.Edges {
+		edge := &order.Edges[i]
+		if edge.Status == elementPending && edge.OrderUpdateID <= stateMsg.OrderUpdateID && !pendingEdges[edge.EdgeID] {
+			edge.Status = elementTraversed
+			edge.Transitions = append(edge.Transitions, StatusTransition{Status: elementTraversed, Timestamp: now})
+		}
+	}
+
+	// Action progress
+	actionStates := make(map[string]ActionState, len(stateMsg.ActionStates))
+	for _, actionState := range stateMsg.ActionStates {
+		actionStates[actionState.ActionID] = actionState
+	}
+	failed, running, finished := false, false, 0
+	var failReason string
+	for i := range order.Actions {
+		action := &order.Actions[i]
+		if actionState, exists := actionStates[action.ActionID]; exists && actionState.ActionStatus != action.Status {
+			action.Status = actionState.ActionStatus
+			action.ResultDescription = actionState.ResultDescription
+			action.Transitions = append(action.Transitions, StatusTransition{Status: action.Status, Timestamp: now})
+		}
+
+		switch action.Status {
+		case "FAILED":
+			failed = true
+			failReason = action.ResultDescription
+		case "FINISHED":
+			finished++
+		case "INITIALIZING", "RUNNING", "PAUSED":
+			running = true
+		}
+	}
+
+	// Derive order status
+	switch {
+	case failed:
+		order.Reason = failReason
+		ot.setOrderStatus(order, OrderFailed, now)
+	case finished == len(order.Actions) && stateMsg.OrderUpdateID >= order.OrderUpdateID &&
+		len(stateMsg.NodeStates) == 0 && len(stateMsg.EdgeStates) == 0:
+		ot.setOrderStatus(order, OrderFinished, now)
+	case running || traversed || finished > 0 || stateMsg.Driving:
+		ot.setOrderStatus(order, OrderRunning, now)
+	case order.Status == OrderWaiting:
+		ot.setOrderStatus(order, OrderInitializing, now)
+	}
+}
+
+// applyMissingOrder updates an order that the robot does not report (anymore)
+func (ot *OrderTracker) applyMissingOrder(order *TrackedOrder, stateMsg *RobotStateMessage, now time.Time) {
+	if order.Status == OrderWaiting {
+		// The robot may not have picked up the order yet
+		if now.Sub(order.IssuedAt) < orderAcceptTimeout {
+			return
+		}
+		order.Reason = "order was never accepted by the robot"
+		ot.setOrderStatus(order, OrderVanished, now)
+		return
+	}
+
+	if stateMsg.OrderID != "" {
+		order.ReplacedBy = stateMsg.OrderID
+		order.Reason = "robot switched to another order"
+		ot.setOrderStatus(order, OrderReplaced, now)
+		return
+	}
+
+	order.Reason = "order disappeared from robot state before finishing"
+	ot.setOrderStatus(order, OrderVanished, now)
+}
+
+// setOrderStatus records an order status transition
+func (ot *OrderTracker) setOrderStatus(order *TrackedOrder, status OrderLifecycleStatus, now time.Time) {
+	if order.Status == status {
+		return
+	}
+	order.Status = status
+	order.Transitions = append(order.Transitions, StatusTransition{Status: string(status), Timestamp: now})
+	if status.IsTerminal() {
+		order.CompletedAt = now
+	}
+}
+
+// pruneFinishedOrders drops the oldest completed orders of a robot beyond the retention limit
+func (ot *OrderTracker) pruneFinishedOrders(robotID string) {
+	orderIDs := ot.robotOrders[robotID]
+	finished := 0
+	for _, orderID := range orderIDs {
+		if ot.orders[orderID].Status.IsTerminal() {
+			finished++
+		}
+	}
+
+	var kept []string
+	for _, orderID := range orderIDs {
+		if finished > maxFinishedOrdersPerRobot && ot.orders[orderID].Status.IsTerminal() {
+			delete(ot.orders, orderID)
+			finished--
+			continue
+		}
+		kept = append(kept, orderID)
+	}
+	ot.robotOrders[robotID] = kept
+}
+
+// GetOrder returns a tracked order by its orderID
+func (ot *OrderTracker) GetOrder(orderID string) (*TrackedOrder, bool) {
+	ot.mutex.RLock()
+	defer ot.mutex.RUnlock()
+
+	order, exists := ot.orders[orderID]
+	if !exists {
+		return nil, false
+	}
+
+	orderCopy := cloneTrackedOrder(order)
+	return &orderCopy, true
+}
+
+// GetRobotOrders returns all tracked orders of a robot, oldest first
+func (ot *OrderTracker) GetRobotOrders(robotID string) []TrackedOrder {
+	ot.mutex.RLock()
+	defer ot.mutex.RUnlock()
+
+	var result []TrackedOrder
+	for _, orderID := range ot.robotOrders[robotID] {
+		if order, exists := ot.orders[orderID]; exists {
+			result = append(result, cloneTrackedOrder(order))
+		}
+	}
+	return result
+}
+
+// GetActiveOrders returns all orders that have not reached a terminal state, oldest first
+func (ot *OrderTracker) GetActiveOrders() []TrackedOrder {
+	ot.mutex.RLock()
+	defer ot.mutex.RUnlock()
+
+	var result []TrackedOrder
+	for _, order := range ot.orders {
+		if !order.Status.IsTerminal() {
+			result = append(result, cloneTrackedOrder(order))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].IssuedAt.Before(result[j].IssuedAt)
+	})
+	return result
+}
+
+// cloneTrackedOrder returns a deep copy of a tracked order
+func cloneTrackedOrder(order *TrackedOrder) TrackedOrder {
+	orderCopy := *order
+	orderCopy.Updates = append([]string(nil), order.Updates...)
+	orderCopy.Transitions = append([]StatusTransition(nil), order.Transitions...)
+
+	orderCopy.Nodes = make([]TrackedNode, len(order.Nodes))
+	for i, node := range order.Nodes {
+		node.Transitions = append([]StatusTransition(nil), node.Transitions...)
+		orderCopy.Nodes[i] = node
+	}
+	orderCopy.Edges = make([]TrackedEdge, len(order.Edges))
+	for i, edge := range order.Edges {
+		edge.Transitions = append([]StatusTransition(nil), edge.Transitions...)
+		orderCopy.Edges[i] = edge
+	}
+	orderCopy.Actions = make([]TrackedAction, len(order.Actions))
+	for i, action := range order.Actions {
+		action.Transitions = append([]StatusTransition(nil), action.Transitions...)
+		orderCopy.Actions[i] = action
+	}
+	return orderCopy
+}
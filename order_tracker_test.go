@@ -0,0 +1,151 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestOrder returns an order for Roboligent/DEX0001 with two nodes, one edge and an action on the last node
+func newTestOrder(orderID string) *OrderMessage {
+	return &OrderMessage{
+		MessageHeader: MessageHeader{Manufacturer: "Roboligent", SerialNumber: "DEX0001"},
+		OrderID:       orderID,
+		Nodes: []Node{
+			{NodeID: "n1", SequenceID: 0, Released: true},
+			{NodeID: "n2", SequenceID: 2, Released: true, Actions: []Action{{ActionID: "a1", ActionType: "pick"}}},
+		},
+		Edges: []Edge{{EdgeID: "e1", SequenceID: 1, Released: true, StartNodeID: "n1", EndNodeID: "n2"}},
+	}
+}
+
+// testState builds a state message of Roboligent/DEX0001
+func testState(orderID string, nodeIDs []string, edgeIDs []string, driving bool, actionStates ...ActionState) *RobotStateMessage {
+	state := &RobotStateMessage{
+		Manufacturer: "Roboligent",
+		SerialNumber: "DEX0001",
+		OrderID:      orderID,
+		Driving:      driving,
+		ActionStates: actionStates,
+	}
+	for _, nodeID := range nodeIDs {
+		state.NodeStates = append(state.NodeStates, NodeState{NodeID: nodeID})
+	}
+	for _, edgeID := range edgeIDs {
+		state.EdgeStates = append(state.EdgeStates, EdgeState{EdgeID: edgeID})
+	}
+	return state
+}
+
+func TestOrderTrackerTransitions(t *testing.T) {
+	accepted := testState("order-1", []string{"n1", "n2"}, []string{"e1"}, false, ActionState{ActionID: "a1", ActionStatus: "WAITING"})
+
+	tests := []struct {
+		name         string
+		states       []*RobotStateMessage
+		wantStatuses []OrderLifecycleStatus // status changes reported to the callback
+		wantReason   string
+	}{
+		{
+			name: "finished",
+			states: []*RobotStateMessage{
+				accepted,
+				testState("order-1", []string{"n2"}, nil, true, ActionState{ActionID: "a1", ActionStatus: "WAITING"}),
+				testState("order-1", nil, nil, false, ActionState{ActionID: "a1", ActionStatus: "RUNNING"}),
+				testState("order-1", nil, nil, false, ActionState{ActionID: "a1", ActionStatus: "FINISHED"}),
+			},
+			wantStatuses: []OrderLifecycleStatus{OrderInitializing, OrderRunning, OrderFinished},
+		},
+		{
+			name: "action failed",
+			states: []*RobotStateMessage{
+				accepted,
+				testState("order-1", nil, nil, false, ActionState{ActionID: "a1", ActionStatus: "FAILED", ResultDescription: "gripper jammed"}),
+			},
+			wantStatuses: []OrderLifecycleStatus{OrderInitializing, OrderFailed},
+			wantReason:   "gripper jammed",
+		},
+		{
+			name: "finished action with nodes left",
+			states: []*RobotStateMessage{
+				accepted,
+				testState("order-1", []string{"n2"}, nil, false, ActionState{ActionID: "a1", ActionStatus: "FINISHED"}),
+			},
+			wantStatuses: []OrderLifecycleStatus{OrderInitializing, OrderRunning},
+		},
+		{
+			name: "replaced by another order",
+			states: []*RobotStateMessage{
+				accepted,
+				testState("order-2", []string{"x1"}, nil, false),
+			},
+			wantStatuses: []OrderLifecycleStatus{OrderInitializing, OrderReplaced},
+			wantReason:   "robot switched to another order",
+		},
+		{
+			name: "vanished from state",
+			states: []*RobotStateMessage{
+				accepted,
+				testState("", nil, nil, false),
+			},
+			wantStatuses: []OrderLifecycleStatus{OrderInitializing, OrderVanished},
+			wantReason:   "order disappeared from robot state before finishing",
+		},
+		{
+			name: "not picked up yet",
+			states: []*RobotStateMessage{
+				testState("", nil, nil, false),
+			},
+			wantStatuses: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewOrderTracker()
+			var statuses []OrderLifecycleStatus
+			tracker.SetOrderStatusCallback(func(order TrackedOrder, oldStatus, newStatus OrderLifecycleStatus) {
+				statuses = append(statuses, newStatus)
+			})
+
+			tracker.TrackOrder("I:test", newTestOrder("order-1"))
+			for _, state := range tt.states {
+				tracker.HandleRobotState(state)
+			}
+
+			if !reflect.DeepEqual(statuses, tt.wantStatuses) {
+				t.Fatalf("status changes = %v, want %v", statuses, tt.wantStatuses)
+			}
+			order, exists := tracker.GetOrder("order-1")
+			if !exists {
+				t.Fatal("order-1 is not tracked")
+			}
+			if order.Reason != tt.wantReason {
+				t.Fatalf("reason = %q, want %q", order.Reason, tt.wantReason)
+			}
+			wantFinal := OrderWaiting
+			if len(tt.wantStatuses) > 0 {
+				wantFinal = tt.wantStatuses[len(tt.wantStatuses)-1]
+			}
+			if order.Status != wantFinal {
+				t.Fatalf("status = %s, want %s", order.Status, wantFinal)
+			}
+		})
+	}
+}
+
+func TestOrderTrackerTraversedElements(t *testing.T) {
+	tracker := NewOrderTracker()
+	tracker.TrackOrder("I:test", newTestOrder("order-1"))
+	tracker.HandleRobotState(testState("order-1", []string{"n2"}, nil, true))
+
+	order, _ := tracker.GetOrder("order-1")
+	got := map[string]string{
+		"n1": order.Nodes[0].Status,
+		"n2": order.Nodes[1].Status,
+		"e1": order.Edges[0].Status,
+	}
+	want := map[string]string{"n1": elementTraversed, "n2": elementPending, "e1": elementTraversed}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("element states = %v, want %v", got, want)
+	}
+}
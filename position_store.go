@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// StoredPosition is the last localized position reported by a robot
+type StoredPosition struct {
+	X          float64   `json:"x"`
+	Y          float64   `json:"y"`
+	Theta      float64   `json:"theta"`
+	MapID      string    `json:"mapId"`
+	LastNodeID string    `json:"lastNodeId,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// PositionStore keeps the last localized position of each robot, optionally persisted to a JSON file
+// so that a robot rebooting mid-floor can be initialized where it stopped
+type PositionStore struct {
+	path      string
+	positions map[string]StoredPosition // robot ID -> last localized position
+	dirty     bool
+	mutex     sync.Mutex
+}
+
+// NewPositionStore creates a position store and loads previously saved positions if path is set
+func NewPositionStore(path string) (*PositionStore, error) {
+	ps := &PositionStore{
+		path:      path,
+		positions: make(map[string]StoredPosition),
+	}
+	if path == "" {
+		return ps, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read position store: %w", err)
+	}
+	if err := json.Unmarshal(data, &ps.positions); err != nil {
+		return nil, fmt.Errorf("failed to parse position store %s: %w", path, err)
+	}
+
+	log.Printf("📍 저장된 로봇 위치 로드 - File: %s, Robots: %d", path, len(ps.positions))
+	return ps, nil
+}
+
+// Update records the position of a robot state message if the robot is localized
+func (ps *PositionStore) Update(robotID string, stateMsg *RobotStateMessage) {
+	position := stateMsg.AGVPosition
+	if !position.PositionInitialized || position.MapID == "" {
+		return
+	}
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	previous := ps.positions[robotID]
+	ps.positions[robotID] = StoredPosition{
+		X:          position.X,
+		Y:          position.Y,
+		Theta:      position.Theta,
+		MapID:      position.MapID,
+		LastNodeID: stateMsg.LastNodeID,
+		UpdatedAt:  time.Now(),
+	}
+	if previous.X != position.X || previous.Y != position.Y || previous.Theta != position.Theta ||
+		previous.MapID != position.MapID || previous.LastNodeID != stateMsg.LastNodeID {
+		ps.dirty = true
+	}
+}
+
+// Get returns the last localized position of a robot
+func (ps *PositionStore) Get(robotID string) (StoredPosition, bool) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	position, exists := ps.positions[robotID]
+	return position, exists
+}
+
+// Save writes changed positions to the store file
+func (ps *PositionStore) Save() error {
+	if ps.path == "" {
+		return nil
+	}
+
+	ps.mutex.Lock()
+	if !ps.dirty {
+		ps.mutex.Unlock()
+		return nil
+	}
+	data, err := json.MarshalIndent(ps.positions, "", "  ")
+	ps.dirty = false
+	ps.mutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode positions: %w", err)
+	}
+
+	if err := writeFileAtomic(ps.path, data); err != nil {
+		// Retry on the next save
+		ps.mutex.Lock()
+		ps.dirty = true
+		ps.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic writes to a temporary file first so a crash never leaves a truncated file
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dedupEntry is a PLC request id seen within the deduplication window
+type dedupEntry struct {
+	serialNumber string
+	action       string
+	receivedAt   time.Time
+	result       *PLCActionResult // latest result reported for the request, nil until the first one
+}
+
+// RequestDeduplicator remembers PLC request ids for a time window so that repeated deliveries
+// of the same request are answered with the original result instead of being dispatched again
+type RequestDeduplicator struct {
+	window  time.Duration
+	entries map[string]*dedupEntry // request ID -> first delivery
+	mutex   sync.Mutex
+}
+
+// NewRequestDeduplicator creates a deduplicator that remembers request ids for the given window
+func NewRequestDeduplicator(window time.Duration) *RequestDeduplicator {
+	return &RequestDeduplicator{
+		window:  window,
+		entries: make(map[string]*dedupEntry),
+	}
+}
+
+// Claim registers the request id of a PLC action
+// For a repeated delivery it returns the latest result of the original request, or ACCEPTED while the original
+// is still in flight, and true; a request id reused for a different command is an error
+func (rd *RequestDeduplicator) Claim(plcAction *PLCActionMessage) (*PLCActionResult, bool, error) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	now := time.Now()
+	rd.pruneLocked(now)
+
+	entry, exists := rd.entries[plcAction.RequestID]
+	if !exists {
+		rd.entries[plcAction.RequestID] = &dedupEntry{
+			serialNumber: plcAction.SerialNumber,
+			action:       plcAction.Action,
+			receivedAt:   now,
+		}
+		return nil, false, nil
+	}
+
+	if entry.serialNumber != plcAction.SerialNumber || entry.action != plcAction.Action {
+		return nil, false, fmt.Errorf("request id %s was already used for %s:%s", plcAction.RequestID, entry.serialNumber, entry.action)
+	}
+	if entry.result == nil {
+		return &PLCActionResult{
+			SerialNumber: entry.serialNumber,
+			Action:       entry.action,
+			RequestID:    plcAction.RequestID,
+			Status:       ResultAccepted,
+			Reason:       "original request is still being processed",
+		}, true, nil
+	}
+	result := *entry.result
+	return &result, true, nil
+}
+
+// HandleResult records the latest result of a request with a request id
+// Results of a different command that reused the id are not recorded
+func (rd *RequestDeduplicator) HandleResult(result PLCActionResult) {
+	if result.RequestID == "" || result.Duplicate {
+		return
+	}
+
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	entry, exists := rd.entries[result.RequestID]
+	if exists && entry.serialNumber == result.SerialNumber && entry.action == result.Action {
+		entry.result = &result
+	}
+}
+
+// Release forgets the request id of a PLC action that was rejected for a transient reason
+// (e.g. the robot was busy), so that a retry with the same id is processed as a new request
+func (rd *RequestDeduplicator) Release(plcAction *PLCActionMessage) {
+	if plcAction.RequestID == "" {
+		return
+	}
+
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	entry, exists := rd.entries[plcAction.RequestID]
+	if exists && entry.serialNumber == plcAction.SerialNumber && entry.action == plcAction.Action {
+		delete(rd.entries, plcAction.RequestID)
+	}
+}
+
+// pruneLocked forgets request ids older than the window
+func (rd *RequestDeduplicator) pruneLocked(now time.Time) {
+	for requestID, entry := range rd.entries {
+		if now.Sub(entry.receivedAt) > rd.window {
+			delete(rd.entries, requestID)
+		}
+	}
+}
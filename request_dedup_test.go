@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestDeduplicator(t *testing.T) {
+	command := &PLCActionMessage{SerialNumber: "DEX0001", Action: "I:inference1", RequestID: "req-1"}
+	published := PLCActionResult{SerialNumber: "DEX0001", Action: "I:inference1", RequestID: "req-1", Status: ResultPublished, OrderID: "order-1"}
+
+	type step struct {
+		claim         *PLCActionMessage // claimed, or nil to record result instead
+		release       bool              // release claim instead of claiming it
+		result        PLCActionResult
+		wantDuplicate bool
+		wantStatus    ActionResultStatus // status answered for a duplicate
+		wantErr       bool
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"first delivery", []step{
+			{claim: command},
+		}},
+		{"redelivery while in flight", []step{
+			{claim: command},
+			{claim: command, wantDuplicate: true, wantStatus: ResultAccepted},
+		}},
+		{"redelivery after a result", []step{
+			{claim: command},
+			{result: published},
+			{claim: command, wantDuplicate: true, wantStatus: ResultPublished},
+		}},
+		{"latest result wins", []step{
+			{claim: command},
+			{result: published},
+			{result: PLCActionResult{SerialNumber: "DEX0001", Action: "I:inference1", RequestID: "req-1", Status: ResultFinished}},
+			{claim: command, wantDuplicate: true, wantStatus: ResultFinished},
+		}},
+		{"duplicate answers are not recorded", []step{
+			{claim: command},
+			{result: published},
+			{result: PLCActionResult{SerialNumber: "DEX0001", Action: "I:inference1", RequestID: "req-1", Status: ResultFailed, Duplicate: true}},
+			{claim: command, wantDuplicate: true, wantStatus: ResultPublished},
+		}},
+		{"results of another command are not recorded", []step{
+			{claim: command},
+			{result: PLCActionResult{SerialNumber: "DEX0002", Action: "I:inference1", RequestID: "req-1", Status: ResultFailed}},
+			{claim: command, wantDuplicate: true, wantStatus: ResultAccepted},
+		}},
+		{"request id reused for another action", []step{
+			{claim: command},
+			{claim: &PLCActionMessage{SerialNumber: "DEX0001", Action: "T:traj1", RequestID: "req-1"}, wantErr: true},
+		}},
+		{"request id reused for another robot", []step{
+			{claim: command},
+			{claim: &PLCActionMessage{SerialNumber: "DEX0002", Action: "I:inference1", RequestID: "req-1"}, wantErr: true},
+		}},
+		{"retry after a transient rejection", []step{
+			{claim: command},
+			{result: PLCActionResult{SerialNumber: "DEX0001", Action: "I:inference1", RequestID: "req-1", Status: ResultRejected}},
+			{claim: command, release: true},
+			{claim: command},
+		}},
+		{"release of another command keeps the request id", []step{
+			{claim: command},
+			{claim: &PLCActionMessage{SerialNumber: "DEX0002", Action: "I:inference1", RequestID: "req-1"}, release: true},
+			{claim: command, wantDuplicate: true, wantStatus: ResultAccepted},
+		}},
+		{"different request ids", []step{
+			{claim: command},
+			{claim: &PLCActionMessage{SerialNumber: "DEX0001", Action: "I:inference1", RequestID: "req-2"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deduplicator := NewRequestDeduplicator(time.Minute)
+			for i, s := range tt.steps {
+				if s.claim == nil {
+					deduplicator.HandleResult(s.result)
+					continue
+				}
+				if s.release {
+					deduplicator.Release(s.claim)
+					continue
+				}
+
+				original, duplicate, err := deduplicator.Claim(s.claim)
+				if (err != nil) != s.wantErr {
+					t.Fatalf("step %d: Claim error = %v, wantErr %t", i, err, s.wantErr)
+				}
+				if duplicate != s.wantDuplicate {
+					t.Fatalf("step %d: Claim duplicate = %t, want %t", i, duplicate, s.wantDuplicate)
+				}
+				if !duplicate {
+					continue
+				}
+				if original == nil || original.Status != s.wantStatus || original.RequestID != s.claim.RequestID {
+					t.Fatalf("step %d: Claim original = %+v, want status %s for %s", i, original, s.wantStatus, s.claim.RequestID)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestDeduplicatorWindow(t *testing.T) {
+	deduplicator := NewRequestDeduplicator(10 * time.Millisecond)
+	command := &PLCActionMessage{SerialNumber: "DEX0001", Action: "I:inference1", RequestID: "req-1"}
+
+	if _, duplicate, _ := deduplicator.Claim(command); duplicate {
+		t.Fatal("first delivery reported as duplicate")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// After the window the request id is forgotten, even for another command
+	other := &PLCActionMessage{SerialNumber: "DEX0002", Action: "T:traj1", RequestID: "req-1"}
+	if _, duplicate, err := deduplicator.Claim(other); duplicate || err != nil {
+		t.Fatalf("Claim after window = (duplicate %t, %v), want a new request", duplicate, err)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dispatchStateTimeout is how long the robot state may take to show a sent order, or a cancelled one
+const dispatchStateTimeout = 30 * time.Second
+
+// BusyPolicy decides what happens to a command for a robot that is still executing an order
+type BusyPolicy string
+
+const (
+	BusySend    BusyPolicy = "send"    // publish right away (instant actions, order updates)
+	BusyReject  BusyPolicy = "reject"  // report REJECTED while the robot is busy
+	BusyQueue   BusyPolicy = "queue"   // wait until the current order finishes
+	BusyPreempt BusyPolicy = "preempt" // cancel the current order, then send
+)
+
+// IsValid reports whether the policy is one of the known policies
+func (bp BusyPolicy) IsValid() bool {
+	switch bp {
+	case BusySend, BusyReject, BusyQueue, BusyPreempt:
+		return true
+	}
+	return false
+}
+
+// ErrRobotBusy is returned for commands rejected because the robot is executing an order
+var ErrRobotBusy = errors.New("robot is busy")
+
+// WaitingCommand is a command waiting for its robot to finish (or cancel) the current order
+type WaitingCommand struct {
+	ID           int64            `json:"id"`
+	RobotID      string           `json:"robotId"`
+	Action       PLCActionMessage `json:"action"`
+	Policy       BusyPolicy       `json:"policy"`
+	QueuedAt     time.Time        `json:"queuedAt"`
+	ExpiresAt    time.Time        `json:"expiresAt"`
+	CancelSentAt time.Time        `json:"cancelSentAt,omitempty"` // preempt: when cancelOrder was sent
+}
+
+// WaitReason describes why the command waits, for its QUEUED result
+func (wc WaitingCommand) WaitReason() string {
+	if wc.Policy == BusyPreempt {
+		return fmt.Sprintf("cancelling the current order of robot %s, sending afterwards", wc.RobotID)
+	}
+	return fmt.Sprintf("waiting for the current order of robot %s to finish, until %s",
+		wc.RobotID, wc.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// sentOrder is an order published to a robot that its state has not reported yet
+type sentOrder struct {
+	orderID string
+	sentAt  time.Time
+}
+
+// RobotDispatcher serializes commands per robot: commands for a busy robot wait in a per-robot FIFO
+// until the state messages show the current order finished
+type RobotDispatcher struct {
+	ttl    time.Duration
+	queues map[string][]*WaitingCommand // robot ID -> waiting commands, next one first
+	sent   map[string]sentOrder         // robot ID -> order sent but not yet in the robot state
+	nextID int64
+	mutex  sync.Mutex
+}
+
+// NewRobotDispatcher creates a dispatcher whose waiting commands expire after ttl
+func NewRobotDispatcher(ttl time.Duration) *RobotDispatcher {
+	return &RobotDispatcher{
+		ttl:    ttl,
+		queues: make(map[string][]*WaitingCommand),
+		sent:   make(map[string]sentOrder),
+		nextID: 1,
+	}
+}
+
+// Enqueue adds a command to the robot's queue
+// Preempting commands go ahead of queued ones; within each, higher priority first, then arrival order
+func (rd *RobotDispatcher) Enqueue(robotID string, plcAction *PLCActionMessage, policy BusyPolicy) WaitingCommand {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	now := time.Now()
+	command := &WaitingCommand{
+		ID:        rd.nextID,
+		RobotID:   robotID,
+		Action:    *plcAction,
+		Policy:    policy,
+		QueuedAt:  now,
+		ExpiresAt: now.Add(rd.ttl),
+	}
+	rd.nextID++
+
+	queue := rd.queues[robotID]
+	position := 0
+	for position < len(queue) && !command.ranksBefore(queue[position]) {
+		position++
+	}
+	rd.queues[robotID] = append(queue[:position:position], append([]*WaitingCommand{command}, queue[position:]...)...)
+	return *command
+}
+
+// ranksBefore reports whether the command is dispatched before another waiting command
+func (wc *WaitingCommand) ranksBefore(other *WaitingCommand) bool {
+	if (wc.Policy == BusyPreempt) != (other.Policy == BusyPreempt) {
+		return wc.Policy == BusyPreempt
+	}
+	return wc.Action.Priority > other.Action.Priority
+}
+
+// Head returns the next waiting command of a robot
+func (rd *RobotDispatcher) Head(robotID string) (WaitingCommand, bool) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	queue := rd.queues[robotID]
+	if len(queue) == 0 {
+		return WaitingCommand{}, false
+	}
+	return *queue[0], true
+}
+
+// Remove deletes a waiting command and returns it
+func (rd *RobotDispatcher) Remove(robotID string, id int64) (WaitingCommand, bool) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	queue := rd.queues[robotID]
+	for i, command := range queue {
+		if command.ID != id {
+			continue
+		}
+		if len(queue) == 1 {
+			delete(rd.queues, robotID)
+		} else {
+			rd.queues[robotID] = append(queue[:i:i], queue[i+1:]...)
+		}
+		return *command, true
+	}
+	return WaitingCommand{}, false
+}
+
+// MarkCancelSent records that cancelOrder was sent for a preempting command
+func (rd *RobotDispatcher) MarkCancelSent(robotID string, id int64) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	for _, command := range rd.queues[robotID] {
+		if command.ID == id {
+			command.CancelSentAt = time.Now()
+			return
+		}
+	}
+}
+
+// HasWaiting reports whether commands are waiting for the robot
+func (rd *RobotDispatcher) HasWaiting(robotID string) bool {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	return len(rd.queues[robotID]) > 0
+}
+
+// Waiting returns the waiting commands of a robot in dispatch order
+func (rd *RobotDispatcher) Waiting(robotID string) []WaitingCommand {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	commands := make([]WaitingCommand, 0, len(rd.queues[robotID]))
+	for _, command := range rd.queues[robotID] {
+		commands = append(commands, *command)
+	}
+	return commands
+}
+
+// Robots returns the IDs of robots with waiting commands, sorted
+func (rd *RobotDispatcher) Robots() []string {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	robotIDs := make([]string, 0, len(rd.queues))
+	for robotID := range rd.queues {
+		robotIDs = append(robotIDs, robotID)
+	}
+	sort.Strings(robotIDs)
+	return robotIDs
+}
+
+// Len returns the number of waiting commands over all robots
+func (rd *RobotDispatcher) Len() int {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	total := 0
+	for _, queue := range rd.queues {
+		total += len(queue)
+	}
+	return total
+}
+
+// NoteOrderSent remembers an order published to a robot until its state reports it
+func (rd *RobotDispatcher) NoteOrderSent(robotID string, orderID string) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	rd.sent[robotID] = sentOrder{orderID: orderID, sentAt: time.Now()}
+}
+
+// NoteRobotState forgets the sent order once the robot state reports it
+func (rd *RobotDispatcher) NoteRobotState(robotID string, orderID string) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	if sent, exists := rd.sent[robotID]; exists && sent.orderID == orderID {
+		delete(rd.sent, robotID)
+	}
+}
+
+// PendingOrder returns the order sent to a robot that its state has not shown yet
+// An order the robot does not report within dispatchStateTimeout is no longer considered pending
+func (rd *RobotDispatcher) PendingOrder(robotID string) (string, bool) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+
+	sent, exists := rd.sent[robotID]
+	if !exists {
+		return "", false
+	}
+	if time.Since(sent.sentAt) > dispatchStateTimeout {
+		delete(rd.sent, robotID)
+		return "", false
+	}
+	return sent.orderID, true
+}
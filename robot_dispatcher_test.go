@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRobotDispatcherOrder(t *testing.T) {
+	type enqueue struct {
+		action   string
+		priority int
+		policy   BusyPolicy
+	}
+
+	tests := []struct {
+		name    string
+		enqueue []enqueue
+		want    []string // actions in dispatch order
+	}{
+		{"arrival order", []enqueue{
+			{"I:a", 0, BusyQueue}, {"I:b", 0, BusyQueue}, {"I:c", 0, BusyQueue},
+		}, []string{"I:a", "I:b", "I:c"}},
+		{"higher priority first", []enqueue{
+			{"I:a", 0, BusyQueue}, {"I:b", 2, BusyQueue}, {"I:c", 1, BusyQueue},
+		}, []string{"I:b", "I:c", "I:a"}},
+		{"equal priority keeps arrival order", []enqueue{
+			{"I:a", 1, BusyQueue}, {"I:b", 1, BusyQueue}, {"I:c", 2, BusyQueue},
+		}, []string{"I:c", "I:a", "I:b"}},
+		{"preempt ahead of queued", []enqueue{
+			{"I:a", 5, BusyQueue}, {"T:home", 0, BusyPreempt}, {"I:b", 0, BusyQueue},
+		}, []string{"T:home", "I:a", "I:b"}},
+		{"preempts by priority", []enqueue{
+			{"T:x", 0, BusyPreempt}, {"T:y", 1, BusyPreempt},
+		}, []string{"T:y", "T:x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := NewRobotDispatcher(time.Minute)
+			for _, e := range tt.enqueue {
+				dispatcher.Enqueue("Roboligent/DEX0001", &PLCActionMessage{Action: e.action, Priority: e.priority}, e.policy)
+			}
+
+			var got []string
+			for _, command := range dispatcher.Waiting("Roboligent/DEX0001") {
+				got = append(got, command.Action.Action)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("dispatch order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotDispatcherRemove(t *testing.T) {
+	dispatcher := NewRobotDispatcher(time.Minute)
+	first := dispatcher.Enqueue("Roboligent/DEX0001", &PLCActionMessage{Action: "I:a"}, BusyQueue)
+	second := dispatcher.Enqueue("Roboligent/DEX0001", &PLCActionMessage{Action: "I:b"}, BusyQueue)
+	dispatcher.Enqueue("Roboligent/DEX0002", &PLCActionMessage{Action: "I:c"}, BusyQueue)
+
+	if _, removed := dispatcher.Remove("Roboligent/DEX0002", first.ID); removed {
+		t.Fatal("removed a command of another robot")
+	}
+	if _, removed := dispatcher.Remove("Roboligent/DEX0001", first.ID); !removed {
+		t.Fatal("command was not removed")
+	}
+	if head, _ := dispatcher.Head("Roboligent/DEX0001"); head.ID != second.ID {
+		t.Fatalf("head = %d, want %d", head.ID, second.ID)
+	}
+
+	dispatcher.Remove("Roboligent/DEX0001", second.ID)
+	if dispatcher.HasWaiting("Roboligent/DEX0001") || dispatcher.Len() != 1 {
+		t.Fatalf("robots with waiting commands = %v, want only DEX0002", dispatcher.Robots())
+	}
+}
+
+func TestRobotDispatcherPendingOrder(t *testing.T) {
+	dispatcher := NewRobotDispatcher(time.Minute)
+	dispatcher.NoteOrderSent("Roboligent/DEX0001", "order-1")
+
+	if orderID, pending := dispatcher.PendingOrder("Roboligent/DEX0001"); !pending || orderID != "order-1" {
+		t.Fatalf("PendingOrder = (%q, %t), want (order-1, true)", orderID, pending)
+	}
+
+	// A state for another order does not clear it; the state showing the order does
+	dispatcher.NoteRobotState("Roboligent/DEX0001", "order-0")
+	if _, pending := dispatcher.PendingOrder("Roboligent/DEX0001"); !pending {
+		t.Fatal("pending order cleared by a state of another order")
+	}
+	dispatcher.NoteRobotState("Roboligent/DEX0001", "order-1")
+	if _, pending := dispatcher.PendingOrder("Roboligent/DEX0001"); pending {
+		t.Fatal("pending order not cleared by the robot state")
+	}
+}
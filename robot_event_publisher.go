@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// RobotEventPublisher publishes robot events as JSON to the per-robot event topic
+type RobotEventPublisher struct {
+	mqttClient *MQTTClient
+	topics     *TopicLayout
+}
+
+// NewRobotEventPublisher creates a new robot event publisher
+func NewRobotEventPublisher(mqttClient *MQTTClient, topics *TopicLayout) *RobotEventPublisher {
+	return &RobotEventPublisher{
+		mqttClient: mqttClient,
+		topics:     topics,
+	}
+}
+
+// HandleEvent publishes a single robot event
+func (rep *RobotEventPublisher) HandleEvent(event RobotEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("❌ 로봇 이벤트 JSON 변환 실패: %v", err)
+		return
+	}
+
+	topic := rep.topics.RobotEventTopic(event.Manufacturer, event.SerialNumber)
+	if err := rep.mqttClient.Publish(topic, payload); err != nil {
+		log.Printf("❌ 로봇 이벤트 발행 실패 - Topic: %s, Event: %s, Error: %v", topic, event.Type, err)
+		return
+	}
+
+	log.Printf("📣 로봇 이벤트 발행 - Topic: %s, Event: %s", topic, event.Type)
+}
@@ -0,0 +1,189 @@
+package main
+
+import "time"
+
+// lowBatteryThreshold is the battery charge (%) below which a robot is considered low on battery
+const lowBatteryThreshold = 20.0
+
+// RobotEventType identifies a normalised robot state change
+type RobotEventType string
+
+const (
+	EventRobotOnline          RobotEventType = "ROBOT_ONLINE"
+	EventRobotOffline         RobotEventType = "ROBOT_OFFLINE"
+	EventConnectionBroken     RobotEventType = "CONNECTION_BROKEN"
+	EventOrderStarted         RobotEventType = "ORDER_STARTED"
+	EventOrderFinished        RobotEventType = "ORDER_FINISHED"
+	EventErrorRaised          RobotEventType = "ERROR_RAISED"
+	EventErrorCleared         RobotEventType = "ERROR_CLEARED"
+	EventEStopEngaged         RobotEventType = "ESTOP_ENGAGED"
+	EventEStopReleased        RobotEventType = "ESTOP_RELEASED"
+	EventBatteryLow           RobotEventType = "BATTERY_LOW"
+	EventChargingStarted      RobotEventType = "CHARGING_STARTED"
+	EventChargingStopped      RobotEventType = "CHARGING_STOPPED"
+	EventDrivingStarted       RobotEventType = "DRIVING_STARTED"
+	EventDrivingStopped       RobotEventType = "DRIVING_STOPPED"
+	EventPaused               RobotEventType = "PAUSED"
+	EventResumed              RobotEventType = "RESUMED"
+	EventOperatingModeChanged RobotEventType = "OPERATING_MODE_CHANGED"
+)
+
+// RobotEvent is a single robot state change, published to the event topic as JSON
+type RobotEvent struct {
+	Type         RobotEventType `json:"type"`
+	RobotID      string         `json:"robotId"`
+	SerialNumber string         `json:"serialNumber"`
+	Manufacturer string         `json:"manufacturer"`
+	Timestamp    time.Time      `json:"timestamp"`
+
+	// Event specific details
+	OrderID       string     `json:"orderId,omitempty"`
+	Error         *ErrorInfo `json:"error,omitempty"`
+	EStop         string     `json:"eStop,omitempty"`
+	BatteryCharge float64    `json:"batteryCharge,omitempty"`
+	PreviousValue string     `json:"previousValue,omitempty"`
+	Value         string     `json:"value,omitempty"`
+}
+
+// RobotEventHandler is a function type for receiving robot events
+type RobotEventHandler func(event RobotEvent)
+
+// newRobotEvent creates an event of the given type for a robot
+func newRobotEvent(eventType RobotEventType, robot *RobotStatus, now time.Time) RobotEvent {
+	return RobotEvent{
+		Type:         eventType,
+		RobotID:      robot.RobotID,
+		SerialNumber: robot.SerialNumber,
+		Manufacturer: robot.Manufacturer,
+		Timestamp:    now,
+	}
+}
+
+// connectionEvent maps a connection state change to an event
+func connectionEvent(robot *RobotStatus, newState ConnectionState, now time.Time) RobotEvent {
+	switch newState {
+	case Online:
+		return newRobotEvent(EventRobotOnline, robot, now)
+	case ConnectionBroken:
+		return newRobotEvent(EventConnectionBroken, robot, now)
+	default:
+		return newRobotEvent(EventRobotOffline, robot, now)
+	}
+}
+
+// diffRobotStates computes the events between two consecutive state messages of a robot
+// previous is nil for the first state message; only conditions that are active then produce events
+func diffRobotStates(robot *RobotStatus, previous, current *RobotStateMessage, now time.Time) []RobotEvent {
+	baseline := previous == nil
+	if baseline {
+		previous = &RobotStateMessage{OperatingMode: current.OperatingMode}
+	}
+
+	var events []RobotEvent
+	add := func(eventType RobotEventType) *RobotEvent {
+		events = append(events, newRobotEvent(eventType, robot, now))
+		return &events[len(events)-1]
+	}
+
+	// Orders
+	if current.OrderID != "" && current.OrderID != previous.OrderID && (!baseline || isOrderActive(current)) {
+		add(EventOrderStarted).OrderID = current.OrderID
+	}
+	if previous.OrderID != "" && isOrderActive(previous) &&
+		(current.OrderID != previous.OrderID || !isOrderActive(current)) {
+		add(EventOrderFinished).OrderID = previous.OrderID
+	}
+
+	// Errors, keyed by type and description
+	previousErrors := indexErrors(previous.Errors)
+	currentErrors := indexErrors(current.Errors)
+	for _, errorInfo := range current.Errors {
+		if _, exists := previousErrors[errorKey(errorInfo)]; !exists {
+			errorCopy := errorInfo
+			add(EventErrorRaised).Error = &errorCopy
+		}
+	}
+	for _, errorInfo := range previous.Errors {
+		if _, exists := currentErrors[errorKey(errorInfo)]; !exists {
+			errorCopy := errorInfo
+			add(EventErrorCleared).Error = &errorCopy
+		}
+	}
+
+	// Safety
+	if isEStopEngaged(current.SafetyState) && !isEStopEngaged(previous.SafetyState) {
+		add(EventEStopEngaged).EStop = current.SafetyState.EStop
+	}
+	if !isEStopEngaged(current.SafetyState) && isEStopEngaged(previous.SafetyState) {
+		add(EventEStopReleased).EStop = current.SafetyState.EStop
+	}
+
+	// Battery; a state without batteryState says nothing about the charge
+	currentCharge := current.BatteryState.BatteryCharge
+	if current.BatteryState.reported && currentCharge < lowBatteryThreshold &&
+		(!previous.BatteryState.reported || previous.BatteryState.BatteryCharge >= lowBatteryThreshold) {
+		add(EventBatteryLow).BatteryCharge = currentCharge
+	}
+	if current.BatteryState.Charging && !previous.BatteryState.Charging {
+		add(EventChargingStarted).BatteryCharge = currentCharge
+	}
+	if !current.BatteryState.Charging && previous.BatteryState.Charging {
+		add(EventChargingStopped).BatteryCharge = currentCharge
+	}
+
+	// Motion
+	if current.Driving && !previous.Driving {
+		add(EventDrivingStarted)
+	}
+	if !current.Driving && previous.Driving {
+		add(EventDrivingStopped)
+	}
+	if current.Paused && !previous.Paused {
+		add(EventPaused)
+	}
+	if !current.Paused && previous.Paused {
+		add(EventResumed)
+	}
+
+	// Operating mode
+	if current.OperatingMode != previous.OperatingMode {
+		event := add(EventOperatingModeChanged)
+		event.PreviousValue = previous.OperatingMode
+		event.Value = current.OperatingMode
+	}
+
+	return events
+}
+
+// isOrderActive reports whether the robot still has nodes, edges or unfinished actions for its order
+func isOrderActive(stateMsg *RobotStateMessage) bool {
+	if len(stateMsg.NodeStates) > 0 || len(stateMsg.EdgeStates) > 0 {
+		return true
+	}
+	for _, actionState := range stateMsg.ActionStates {
+		switch actionState.ActionStatus {
+		case "WAITING", "INITIALIZING", "RUNNING", "PAUSED":
+			return true
+		}
+	}
+	return false
+}
+
+// isEStopEngaged reports whether an emergency stop is active
+func isEStopEngaged(safetyState SafetyState) bool {
+	return safetyState.EStop != "" && safetyState.EStop != "NONE"
+}
+
+// errorKey identifies an error across state messages
+func errorKey(errorInfo ErrorInfo) string {
+	return errorInfo.ErrorType + "|" + errorInfo.ErrorDescription
+}
+
+// indexErrors maps errors by their key
+func indexErrors(errors []ErrorInfo) map[string]ErrorInfo {
+	indexed := make(map[string]ErrorInfo, len(errors))
+	for _, errorInfo := range errors {
+		indexed[errorKey(errorInfo)] = errorInfo
+	}
+	return indexed
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// decodeState parses a state message the way it arrives from the robot
+func decodeState(t *testing.T, payload string) *RobotStateMessage {
+	t.Helper()
+	var state RobotStateMessage
+	if err := json.Unmarshal([]byte(payload), &state); err != nil {
+		t.Fatal(err)
+	}
+	return &state
+}
+
+func TestConnectionEvent(t *testing.T) {
+	robot := &RobotStatus{RobotID: "Roboligent/DEX0001", SerialNumber: "DEX0001", Manufacturer: "Roboligent"}
+
+	tests := []struct {
+		state ConnectionState
+		want  RobotEventType
+	}{
+		{Online, EventRobotOnline},
+		{Offline, EventRobotOffline},
+		{ConnectionBroken, EventConnectionBroken},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			event := connectionEvent(robot, tt.state, time.Now())
+			if event.Type != tt.want || event.RobotID != robot.RobotID {
+				t.Fatalf("connectionEvent(%s) = %s for %s, want %s for %s", tt.state, event.Type, event.RobotID, tt.want, robot.RobotID)
+			}
+		})
+	}
+}
+
+func TestDiffRobotStates(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string // empty for the first state message of the robot
+		current  string
+		want     []RobotEventType
+	}{
+		{"first state without battery state", "",
+			`{"operatingMode": "AUTOMATIC"}`, nil},
+		{"first state with healthy battery", "",
+			`{"batteryState": {"batteryCharge": 80}}`, nil},
+		{"first state with low battery", "",
+			`{"batteryState": {"batteryCharge": 15}}`, []RobotEventType{EventBatteryLow}},
+		{"battery drops below threshold",
+			`{"batteryState": {"batteryCharge": 21}}`,
+			`{"batteryState": {"batteryCharge": 19}}`, []RobotEventType{EventBatteryLow}},
+		{"battery stays low",
+			`{"batteryState": {"batteryCharge": 19}}`,
+			`{"batteryState": {"batteryCharge": 18}}`, nil},
+		{"battery state missing after low battery",
+			`{"batteryState": {"batteryCharge": 19}}`,
+			`{}`, nil},
+		{"battery state appears low",
+			`{}`,
+			`{"batteryState": {"batteryCharge": 10}}`, []RobotEventType{EventBatteryLow}},
+		{"charging started",
+			`{"batteryState": {"batteryCharge": 50}}`,
+			`{"batteryState": {"batteryCharge": 50, "charging": true}}`, []RobotEventType{EventChargingStarted}},
+		{"charging stopped",
+			`{"batteryState": {"batteryCharge": 90, "charging": true}}`,
+			`{"batteryState": {"batteryCharge": 90}}`, []RobotEventType{EventChargingStopped}},
+		{"error raised",
+			`{"errors": []}`,
+			`{"errors": [{"errorType": "laser", "errorDescription": "blocked", "errorLevel": "WARNING"}]}`,
+			[]RobotEventType{EventErrorRaised}},
+		{"error cleared",
+			`{"errors": [{"errorType": "laser", "errorDescription": "blocked", "errorLevel": "WARNING"}]}`,
+			`{"errors": []}`, []RobotEventType{EventErrorCleared}},
+		{"error description changed",
+			`{"errors": [{"errorType": "laser", "errorDescription": "blocked"}]}`,
+			`{"errors": [{"errorType": "laser", "errorDescription": "dirty"}]}`,
+			[]RobotEventType{EventErrorRaised, EventErrorCleared}},
+		{"first state with error", "",
+			`{"errors": [{"errorType": "laser", "errorDescription": "blocked"}]}`,
+			[]RobotEventType{EventErrorRaised}},
+		{"e-stop released",
+			`{"safetyState": {"eStop": "AUTOACK"}}`,
+			`{"safetyState": {"eStop": "NONE"}}`, []RobotEventType{EventEStopReleased}},
+		{"order started and driving",
+			`{"orderId": ""}`,
+			`{"orderId": "order-1", "driving": true, "nodeStates": [{"nodeId": "n2"}]}`,
+			[]RobotEventType{EventOrderStarted, EventDrivingStarted}},
+		{"order finished",
+			`{"orderId": "order-1", "nodeStates": [{"nodeId": "n2"}]}`,
+			`{"orderId": "order-1"}`, []RobotEventType{EventOrderFinished}},
+		{"finished order on first state", "",
+			`{"orderId": "order-1"}`, nil},
+		{"paused",
+			`{"paused": false}`,
+			`{"paused": true}`, []RobotEventType{EventPaused}},
+		{"operating mode changed",
+			`{"operatingMode": "AUTOMATIC"}`,
+			`{"operatingMode": "MANUAL"}`, []RobotEventType{EventOperatingModeChanged}},
+	}
+
+	robot := &RobotStatus{RobotID: "Roboligent/DEX0001", SerialNumber: "DEX0001", Manufacturer: "Roboligent"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var previous *RobotStateMessage
+			if tt.previous != "" {
+				previous = decodeState(t, tt.previous)
+			}
+
+			var got []RobotEventType
+			for _, event := range diffRobotStates(robot, previous, decodeState(t, tt.current), time.Now()) {
+				got = append(got, event.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
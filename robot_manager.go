@@ -1,36 +1,64 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// broadcastTarget addresses every target robot in a PLC command ("*:startPause")
+const broadcastTarget = "*"
+
+// groupTargetPrefix starts a PLC command target naming a robot group ("@cellA:cancelOrder")
+const groupTargetPrefix = "@"
+
 // StatusChangeCallback is a function type for handling robot status changes
-type StatusChangeCallback func(serialNumber string, oldState, newState ConnectionState)
+type StatusChangeCallback func(robotID string, oldState, newState ConnectionState)
 
 // RobotManager manages multiple robots' connection states
+// Robots are keyed by robot ID ("manufacturer/serial") so serials may repeat across manufacturers
 type RobotManager struct {
 	robots               map[string]*RobotStatus
-	targetSerials        map[string]bool // 관리 대상 로봇 시리얼 번호 목록
+	factsheets           map[string]*FactsheetResponseMessage // 로봇별 최신 Factsheet
+	targets              map[string]bool                      // 관리 대상 로봇 목록 (serial 또는 manufacturer/serial)
+	groups               map[string][]string                  // 로봇 그룹 이름 -> 대상 로봇 목록
+	positions            *PositionStore                       // 로봇별 마지막 위치 (자동 초기화용)
 	mutex                sync.RWMutex
 	statusChangeCallback StatusChangeCallback // 상태 변경 콜백
+	eventHandlers        []RobotEventHandler  // 로봇 이벤트 구독자
 }
 
-// NewRobotManager creates a new robot manager with target serials
-func NewRobotManager(targetSerials []string) *RobotManager {
-	// Create target serials map for quick lookup
+// NewRobotManager creates a new robot manager with target robots, named groups of them and a store for their last positions
+// Each target is either a bare serial (any manufacturer) or "manufacturer/serial"
+func NewRobotManager(targets []string, groups map[string][]string, positions *PositionStore) *RobotManager {
+	// Create targets map for quick lookup
 	targetMap := make(map[string]bool)
-	for _, serial := range targetSerials {
-		targetMap[serial] = true
+	for _, target := range targets {
+		targetMap[target] = true
 	}
 
 	return &RobotManager{
-		robots:        make(map[string]*RobotStatus),
-		targetSerials: targetMap,
+		robots:     make(map[string]*RobotStatus),
+		factsheets: make(map[string]*FactsheetResponseMessage),
+		targets:    targetMap,
+		groups:     groups,
+		positions:  positions,
 	}
 }
 
+// makeRobotID builds the identifier used to track a robot of a given manufacturer
+func makeRobotID(manufacturer string, serialNumber string) string {
+	return manufacturer + "/" + serialNumber
+}
+
+// isTarget checks the target list without locking
+func (rm *RobotManager) isTarget(manufacturer string, serialNumber string) bool {
+	return rm.targets[serialNumber] || rm.targets[makeRobotID(manufacturer, serialNumber)]
+}
+
 // SetStatusChangeCallback sets the callback function for status changes
 func (rm *RobotManager) SetStatusChangeCallback(callback StatusChangeCallback) {
 	rm.mutex.Lock()
@@ -38,34 +66,64 @@ func (rm *RobotManager) SetStatusChangeCallback(callback StatusChangeCallback) {
 	rm.statusChangeCallback = callback
 }
 
+// SubscribeEvents registers a handler for robot events
+// Handlers are called outside the manager lock in the order the events occurred
+func (rm *RobotManager) SubscribeEvents(handler RobotEventHandler) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.eventHandlers = append(rm.eventHandlers, handler)
+}
+
+// emitEvents delivers events to all subscribers; must be called without holding the lock
+func (rm *RobotManager) emitEvents(events []RobotEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	rm.mutex.RLock()
+	handlers := rm.eventHandlers
+	rm.mutex.RUnlock()
+
+	for _, event := range events {
+		for _, handler := range handlers {
+			handler(event)
+		}
+	}
+}
+
 // UpdateRobotConnectionStatus updates robot status from basic connection message
 func (rm *RobotManager) UpdateRobotConnectionStatus(msg *RobotConnectionMessage) {
+	var events []RobotEvent
+	defer func() { rm.emitEvents(events) }() // runs after the lock is released
+
 	rm.mutex.Lock()
 	defer rm.mutex.Unlock()
 
 	serialNumber := msg.SerialNumber
+	robotID := makeRobotID(msg.Manufacturer, serialNumber)
 
 	// Check if this robot is in target list
-	if !rm.targetSerials[serialNumber] {
-		log.Printf("⚠️  관리 대상이 아닌 로봇 연결 메시지 무시 - Serial: %s", serialNumber)
+	if !rm.isTarget(msg.Manufacturer, serialNumber) {
+		log.Printf("⚠️  관리 대상이 아닌 로봇 연결 메시지 무시 - Robot: %s", robotID)
 		return
 	}
 
 	// Get existing robot or create new one
-	robot, exists := rm.robots[serialNumber]
+	robot, exists := rm.robots[robotID]
 	if !exists {
 		robot = &RobotStatus{
+			RobotID:      robotID,
 			SerialNumber: serialNumber,
 			Manufacturer: msg.Manufacturer,
 		}
-		rm.robots[serialNumber] = robot
-		log.Printf("✅ 새로운 로봇 등록 (연결) - Serial: %s", serialNumber)
+		rm.robots[robotID] = robot
+		log.Printf("✅ 새로운 로봇 등록 (연결) - Robot: %s", robotID)
 	}
 
 	// Check if this is a newer message
 	if robot.HasConnectionInfo && robot.LastHeaderID > msg.HeaderID {
 		log.Printf("⚠️  이전 연결 메시지 무시 - Robot: %s, Current HeaderID: %d, Received HeaderID: %d",
-			serialNumber, robot.LastHeaderID, msg.HeaderID)
+			robotID, robot.LastHeaderID, msg.HeaderID)
 		return
 	}
 
@@ -82,43 +140,53 @@ func (rm *RobotManager) UpdateRobotConnectionStatus(msg *RobotConnectionMessage)
 
 	// Log state changes
 	if previousState != msg.ConnectionState {
-		log.Printf("🔄 로봇 연결 상태 변경 - Serial: %s, %s -> %s",
-			serialNumber, previousState, msg.ConnectionState)
+		log.Printf("🔄 로봇 연결 상태 변경 - Robot: %s, %s -> %s",
+			robotID, previousState, msg.ConnectionState)
+		events = append(events, connectionEvent(robot, msg.ConnectionState, time.Now()))
 
 		// Call status change callback if set
 		if rm.statusChangeCallback != nil {
 			// Release lock before calling callback to avoid deadlock
 			rm.mutex.Unlock()
-			rm.statusChangeCallback(serialNumber, previousState, msg.ConnectionState)
+			rm.statusChangeCallback(robotID, previousState, msg.ConnectionState)
 			rm.mutex.Lock()
 		}
 	}
 }
 
 // UpdateRobotStateStatus updates detailed robot status from state messages
+// and emits the events found by comparing it with the previous state message
 func (rm *RobotManager) UpdateRobotStateStatus(stateMsg *RobotStateMessage) {
+	var events []RobotEvent
+	defer func() { rm.emitEvents(events) }() // runs after the lock is released
+
 	rm.mutex.Lock()
 	defer rm.mutex.Unlock()
 
 	serialNumber := stateMsg.SerialNumber
+	robotID := makeRobotID(stateMsg.Manufacturer, serialNumber)
 
 	// Check if this robot is in target list
-	if !rm.targetSerials[serialNumber] {
-		log.Printf("⚠️  관리 대상이 아닌 로봇 상태 메시지 무시 - Serial: %s", serialNumber)
+	if !rm.isTarget(stateMsg.Manufacturer, serialNumber) {
+		log.Printf("⚠️  관리 대상이 아닌 로봇 상태 메시지 무시 - Robot: %s", robotID)
 		return
 	}
 
 	// Get existing robot or create new one
-	robot, exists := rm.robots[serialNumber]
+	robot, exists := rm.robots[robotID]
 	if !exists {
 		robot = &RobotStatus{
+			RobotID:      robotID,
 			SerialNumber: serialNumber,
 			Manufacturer: stateMsg.Manufacturer,
 		}
-		rm.robots[serialNumber] = robot
-		log.Printf("✅ 새로운 로봇 등록 (상태) - Serial: %s", serialNumber)
+		rm.robots[robotID] = robot
+		log.Printf("✅ 새로운 로봇 등록 (상태) - Robot: %s", robotID)
 	}
 
+	// Compute events against the previous state message
+	events = diffRobotStates(robot, robot.DetailedStatus, stateMsg, time.Now())
+
 	// Update detailed status
 	robot.DetailedStatus = stateMsg
 	robot.DetailedUpdate = time.Now()
@@ -130,7 +198,6 @@ func (rm *RobotManager) UpdateRobotStateStatus(stateMsg *RobotStateMessage) {
 	if !robot.HasConnectionInfo || stateMsg.HeaderID > robot.LastHeaderID {
 		robot.LastUpdate = time.Now()
 		robot.LastHeaderID = stateMsg.HeaderID
-		robot.Manufacturer = stateMsg.Manufacturer
 	}
 
 	// Update order execution state from detailed status
@@ -144,6 +211,7 @@ func (rm *RobotManager) UpdateRobotStateStatus(stateMsg *RobotStateMessage) {
 
 	// Update position and battery from detailed status
 	robot.CurrentPosition = &stateMsg.AGVPosition
+	rm.positions.Update(robotID, stateMsg)
 	robot.BatteryLevel = stateMsg.BatteryState.BatteryCharge // 실제 필드명 사용
 	robot.IsCharging = stateMsg.BatteryState.Charging        // 실제 필드명 사용
 
@@ -170,12 +238,108 @@ func (rm *RobotManager) UpdateRobotStateStatus(stateMsg *RobotStateMessage) {
 	}
 }
 
+// ResolveRobotID resolves a PLC/API target ("serial" or "manufacturer/serial") to a robot ID
+func (rm *RobotManager) ResolveRobotID(target string) (string, error) {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	// Fully qualified target
+	if manufacturer, serialNumber, qualified := strings.Cut(target, "/"); qualified {
+		if !rm.isTarget(manufacturer, serialNumber) {
+			return "", classifyError(ErrUnknownTarget, fmt.Errorf("robot %s is not in target list", target))
+		}
+		return target, nil
+	}
+
+	// Bare serial: look up the manufacturer from registered robots
+	var matches []string
+	for robotID, robot := range rm.robots {
+		if robot.SerialNumber == target {
+			matches = append(matches, robotID)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		if rm.targets[target] {
+			return "", fmt.Errorf("robot %s is not online", target)
+		}
+		return "", classifyError(ErrUnknownTarget, fmt.Errorf("robot %s is not in target list", target))
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("serial %s is used by multiple manufacturers %v, use manufacturer/serial", target, matches)
+	}
+}
+
+// isFanOutTarget reports whether a PLC command target addresses several robots ("*" or "@group")
+func isFanOutTarget(target string) bool {
+	return target == broadcastTarget || strings.HasPrefix(target, groupTargetPrefix)
+}
+
+// ExpandTarget resolves a fan-out target: "*" addresses all target robots and "@group" the members of a group
+// Bare serials expand to every registered robot with that serial; unregistered entries are kept so that
+// sending to them reports the robot as not online
+// The second result is false for a single robot target
+func (rm *RobotManager) ExpandTarget(target string) ([]string, bool, error) {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	if !isFanOutTarget(target) {
+		return []string{target}, false, nil
+	}
+
+	var entries []string
+	if target == broadcastTarget {
+		for entry := range rm.targets {
+			entries = append(entries, entry)
+		}
+		sort.Strings(entries)
+	} else {
+		members, exists := rm.groups[strings.TrimPrefix(target, groupTargetPrefix)]
+		if !exists {
+			return nil, true, classifyError(ErrUnknownTarget, fmt.Errorf("unknown robot group %s", target))
+		}
+		entries = members
+	}
+
+	seen := make(map[string]bool)
+	var robotTargets []string
+	for _, entry := range entries {
+		expanded := []string{entry}
+		if !strings.Contains(entry, "/") {
+			if matches := rm.robotIDsWithSerial(entry); len(matches) > 0 {
+				expanded = matches
+			}
+		}
+		for _, robotTarget := range expanded {
+			if !seen[robotTarget] {
+				seen[robotTarget] = true
+				robotTargets = append(robotTargets, robotTarget)
+			}
+		}
+	}
+	return robotTargets, true, nil
+}
+
+// robotIDsWithSerial returns the sorted IDs of registered robots with a serial number without locking
+func (rm *RobotManager) robotIDsWithSerial(serialNumber string) []string {
+	var robotIDs []string
+	for robotID, robot := range rm.robots {
+		if robot.SerialNumber == serialNumber {
+			robotIDs = append(robotIDs, robotID)
+		}
+	}
+	sort.Strings(robotIDs)
+	return robotIDs
+}
+
 // GetRobotStatus returns the current status of a robot
-func (rm *RobotManager) GetRobotStatus(serialNumber string) (*RobotStatus, bool) {
+func (rm *RobotManager) GetRobotStatus(robotID string) (*RobotStatus, bool) {
 	rm.mutex.RLock()
 	defer rm.mutex.RUnlock()
 
-	robot, exists := rm.robots[serialNumber]
+	robot, exists := rm.robots[robotID]
 	if !exists {
 		return nil, false
 	}
@@ -198,9 +362,14 @@ func (rm *RobotManager) GetAllRobots() map[string]*RobotStatus {
 	return result
 }
 
+// GetLastKnownPosition returns the last localized position reported by a robot
+func (rm *RobotManager) GetLastKnownPosition(robotID string) (StoredPosition, bool) {
+	return rm.positions.Get(robotID)
+}
+
 // IsRobotOnline checks if a robot is online
-func (rm *RobotManager) IsRobotOnline(serialNumber string) bool {
-	robot, exists := rm.GetRobotStatus(serialNumber)
+func (rm *RobotManager) IsRobotOnline(robotID string) bool {
+	robot, exists := rm.GetRobotStatus(robotID)
 	return exists && robot.ConnectionState == Online
 }
 
@@ -210,38 +379,38 @@ func (rm *RobotManager) GetOnlineRobots() []string {
 	defer rm.mutex.RUnlock()
 
 	var onlineRobots []string
-	for serialNumber, robot := range rm.robots {
+	for robotID, robot := range rm.robots {
 		if robot.ConnectionState == Online {
-			onlineRobots = append(onlineRobots, serialNumber)
+			onlineRobots = append(onlineRobots, robotID)
 		}
 	}
 	return onlineRobots
 }
 
-// GetTargetSerials returns the list of target robot serials
+// GetTargetSerials returns the list of target robot entries
 func (rm *RobotManager) GetTargetSerials() []string {
 	rm.mutex.RLock()
 	defer rm.mutex.RUnlock()
 
 	var serials []string
-	for serial := range rm.targetSerials {
-		serials = append(serials, serial)
+	for target := range rm.targets {
+		serials = append(serials, target)
 	}
 	return serials
 }
 
-// IsTargetRobot checks if a robot serial is in the target list
-func (rm *RobotManager) IsTargetRobot(serialNumber string) bool {
+// IsTargetRobot checks if a robot of a manufacturer is in the target list
+func (rm *RobotManager) IsTargetRobot(manufacturer string, serialNumber string) bool {
 	rm.mutex.RLock()
 	defer rm.mutex.RUnlock()
-	return rm.targetSerials[serialNumber]
+	return rm.isTarget(manufacturer, serialNumber)
 }
 
 // GetTargetRobotCount returns the total number of target robots
 func (rm *RobotManager) GetTargetRobotCount() int {
 	rm.mutex.RLock()
 	defer rm.mutex.RUnlock()
-	return len(rm.targetSerials)
+	return len(rm.targets)
 }
 
 // GetRegisteredTargetRobots returns target robots that have been registered (sent status at least once)
@@ -251,7 +420,7 @@ func (rm *RobotManager) GetRegisteredTargetRobots() map[string]*RobotStatus {
 
 	result := make(map[string]*RobotStatus)
 	for k, v := range rm.robots {
-		if rm.targetSerials[k] {
+		if rm.isTarget(v.Manufacturer, v.SerialNumber) {
 			robotCopy := *v
 			result[k] = &robotCopy
 		}
@@ -265,26 +434,65 @@ func (rm *RobotManager) GetMissingTargetRobots() []string {
 	defer rm.mutex.RUnlock()
 
 	var missing []string
-	for serial := range rm.targetSerials {
-		if _, exists := rm.robots[serial]; !exists {
-			missing = append(missing, serial)
+	for target := range rm.targets {
+		if !rm.isTargetRegistered(target) {
+			missing = append(missing, target)
 		}
 	}
 	return missing
 }
 
-// UpdateFactsheetReceived updates the factsheet received status for a robot
-func (rm *RobotManager) UpdateFactsheetReceived(serialNumber string) {
+// isTargetRegistered checks whether any robot matching a target entry has registered
+func (rm *RobotManager) isTargetRegistered(target string) bool {
+	if strings.Contains(target, "/") {
+		_, exists := rm.robots[target]
+		return exists
+	}
+	for _, robot := range rm.robots {
+		if robot.SerialNumber == target {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateFactsheet stores the latest factsheet of a robot and logs capability changes
+func (rm *RobotManager) UpdateFactsheet(factsheet *FactsheetResponseMessage) {
 	rm.mutex.Lock()
 	defer rm.mutex.Unlock()
 
-	if robot, exists := rm.robots[serialNumber]; exists {
+	robotID := makeRobotID(factsheet.Manufacturer, factsheet.SerialNumber)
+
+	// Compare with previously received factsheet
+	if previous, exists := rm.factsheets[robotID]; exists {
+		if diff := diffFactsheets(previous, factsheet); !diff.IsEmpty() {
+			log.Printf("🔔 로봇 Factsheet 변경 감지 - Robot: %s, %s", robotID, diff)
+		}
+	}
+	rm.factsheets[robotID] = factsheet
+
+	if robot, exists := rm.robots[robotID]; exists {
 		robot.HasFactsheet = true
 		robot.FactsheetUpdate = time.Now()
-		log.Printf("📋 로봇 Factsheet 수신 완료 - Serial: %s", serialNumber)
+		log.Printf("📋 로봇 Factsheet 수신 완료 - Robot: %s", robotID)
 	}
 }
 
+// GetRobotFactsheet returns the latest factsheet received from a robot
+func (rm *RobotManager) GetRobotFactsheet(robotID string) (*FactsheetResponseMessage, bool) {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	factsheet, exists := rm.factsheets[robotID]
+	if !exists {
+		return nil, false
+	}
+
+	// Stored factsheets are replaced, never modified, so a shallow copy is sufficient
+	factsheetCopy := *factsheet
+	return &factsheetCopy, true
+}
+
 // GetExecutingRobots returns robots currently executing orders
 func (rm *RobotManager) GetExecutingRobots() map[string]*RobotStatus {
 	rm.mutex.RLock()
@@ -292,7 +500,7 @@ func (rm *RobotManager) GetExecutingRobots() map[string]*RobotStatus {
 
 	result := make(map[string]*RobotStatus)
 	for k, v := range rm.robots {
-		if v.IsExecutingOrder && rm.targetSerials[k] {
+		if v.IsExecutingOrder && rm.isTarget(v.Manufacturer, v.SerialNumber) {
 			robotCopy := *v
 			result[k] = &robotCopy
 		}
@@ -307,7 +515,7 @@ func (rm *RobotManager) GetRobotsWithErrors() map[string]*RobotStatus {
 
 	result := make(map[string]*RobotStatus)
 	for k, v := range rm.robots {
-		if (v.LastError != nil || v.HasSafetyIssue) && rm.targetSerials[k] {
+		if (v.LastError != nil || v.HasSafetyIssue) && rm.isTarget(v.Manufacturer, v.SerialNumber) {
 			robotCopy := *v
 			result[k] = &robotCopy
 		}
@@ -322,7 +530,7 @@ func (rm *RobotManager) GetLowBatteryRobots(threshold float64) map[string]*Robot
 
 	result := make(map[string]*RobotStatus)
 	for k, v := range rm.robots {
-		if v.BatteryLevel > 0 && v.BatteryLevel < threshold && rm.targetSerials[k] {
+		if v.BatteryLevel > 0 && v.BatteryLevel < threshold && rm.isTarget(v.Manufacturer, v.SerialNumber) {
 			robotCopy := *v
 			result[k] = &robotCopy
 		}
@@ -337,7 +545,7 @@ func (rm *RobotManager) GetRobotsWithDetailedStatus() map[string]*RobotStatus {
 
 	result := make(map[string]*RobotStatus)
 	for k, v := range rm.robots {
-		if v.HasDetailedInfo && rm.targetSerials[k] {
+		if v.HasDetailedInfo && rm.isTarget(v.Manufacturer, v.SerialNumber) {
 			robotCopy := *v
 			result[k] = &robotCopy
 		}
@@ -352,7 +560,7 @@ func (rm *RobotManager) GetRobotBatteryStatus() map[string]BatteryState {
 
 	result := make(map[string]BatteryState)
 	for k, v := range rm.robots {
-		if v.HasDetailedInfo && v.DetailedStatus != nil && rm.targetSerials[k] {
+		if v.HasDetailedInfo && v.DetailedStatus != nil && rm.isTarget(v.Manufacturer, v.SerialNumber) {
 			result[k] = v.DetailedStatus.BatteryState // 직접 BatteryState 반환
 		}
 	}
@@ -366,7 +574,7 @@ func (rm *RobotManager) GetActiveRobotOrders() map[string]ActiveOrder {
 
 	result := make(map[string]ActiveOrder)
 	for k, v := range rm.robots {
-		if v.IsExecutingOrder && rm.targetSerials[k] {
+		if v.IsExecutingOrder && rm.isTarget(v.Manufacturer, v.SerialNumber) {
 			result[k] = ActiveOrder{
 				OrderID:       v.CurrentOrderID,
 				IsDriving:     v.IsDriving,
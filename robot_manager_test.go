@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRobotManagerExpandTarget(t *testing.T) {
+	rm := NewRobotManager(
+		[]string{"DEX0001", "Roboligent/DEX0002", "Acme/DEX0003"},
+		map[string][]string{
+			"cellA": {"DEX0001", "Roboligent/DEX0002"},
+			"cellB": {"Acme/DEX0003", "DEX0001", "DEX0001"},
+		},
+		nil,
+	)
+
+	tests := []struct {
+		target     string
+		want       []string
+		wantFanOut bool
+		wantErr    error
+	}{
+		{"DEX0001", []string{"DEX0001"}, false, nil},
+		{"Roboligent/DEX0002", []string{"Roboligent/DEX0002"}, false, nil},
+		{"*", []string{"Acme/DEX0003", "DEX0001", "Roboligent/DEX0002"}, true, nil},
+		{"@cellA", []string{"DEX0001", "Roboligent/DEX0002"}, true, nil},
+		{"@cellB", []string{"Acme/DEX0003", "DEX0001"}, true, nil},
+		{"@cellC", nil, true, ErrUnknownTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, fanOut, err := rm.ExpandTarget(tt.target)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("ExpandTarget(%q) error = %v, want %v", tt.target, err, tt.wantErr)
+			}
+			if fanOut != tt.wantFanOut || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ExpandTarget(%q) = (%v, %t), want (%v, %t)", tt.target, got, fanOut, tt.want, tt.wantFanOut)
+			}
+		})
+	}
+}
+
+func TestRobotManagerResolveRobotIDUnknownTarget(t *testing.T) {
+	rm := NewRobotManager([]string{"DEX0001"}, nil, nil)
+
+	for _, target := range []string{"DEX0009", "Acme/DEX0009"} {
+		if _, err := rm.ResolveRobotID(target); !errors.Is(err, ErrUnknownTarget) {
+			t.Fatalf("ResolveRobotID(%q) error = %v, want %v", target, err, ErrUnknownTarget)
+		}
+	}
+
+	// A target robot that has not registered yet is known but not online
+	if _, err := rm.ResolveRobotID("DEX0001"); err == nil || errors.Is(err, ErrUnknownTarget) {
+		t.Fatalf("ResolveRobotID(DEX0001) error = %v, want robot not online", err)
+	}
+}
+
+func TestIsTargetRobot(t *testing.T) {
+	targets := []string{"DEX0001", "Roboligent/DEX0002", "Acme/DEX0003"}
+
+	tests := []struct {
+		member string
+		want   bool
+	}{
+		{"DEX0001", true},
+		{"Acme/DEX0001", true}, // bare target serials match any manufacturer
+		{"DEX0002", true},
+		{"Roboligent/DEX0002", true},
+		{"Acme/DEX0002", false},
+		{"DEX0003", false}, // bare members use the default manufacturer
+		{"Acme/DEX0003", true},
+		{"DEX0009", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTargetRobot(tt.member, targets, "Roboligent"); got != tt.want {
+			t.Errorf("isTargetRobot(%q) = %t, want %t", tt.member, got, tt.want)
+		}
+	}
+}
@@ -29,7 +29,7 @@ func NewRobotStatusMonitor(robotManager *RobotManager, messageProcessor *Message
 }
 
 // handleRobotStatusChange handles robot status changes and sends init command when robot comes online
-func (rsm *RobotStatusMonitor) handleRobotStatusChange(serialNumber string, oldState, newState ConnectionState) {
+func (rsm *RobotStatusMonitor) handleRobotStatusChange(robotID string, oldState, newState ConnectionState) {
 	// Check if auto init is enabled
 	if !rsm.config.App.AutoInitOnConnect {
 		return
@@ -37,46 +37,44 @@ func (rsm *RobotStatusMonitor) handleRobotStatusChange(serialNumber string, oldS
 
 	// Check if robot changed from non-ONLINE to ONLINE
 	if oldState != Online && newState == Online {
-		log.Printf("🤖 로봇 온라인 감지 - 자동 위치 초기화 시작: %s", serialNumber)
+		log.Printf("🤖 로봇 온라인 감지 - 자동 위치 초기화 시작: %s", robotID)
 
-		// Create init action for the robot
-		initAction := &PLCActionMessage{
-			Action:       "init",
-			SerialNumber: serialNumber,
-		}
+		// Choose where to initialize the robot
+		pose, initialize := rsm.autoInitPose(robotID)
 
 		// Send init action to the robot (with configurable delay)
 		go func() {
 			// Wait for robot to fully initialize
 			delayDuration := time.Duration(rsm.config.App.AutoInitDelaySec) * time.Second
-			log.Printf("⏳ 자동 초기화 대기 중 (%ds): %s", rsm.config.App.AutoInitDelaySec, serialNumber)
+			log.Printf("⏳ 자동 초기화 대기 중 (%ds): %s", rsm.config.App.AutoInitDelaySec, robotID)
 			time.Sleep(delayDuration)
 
 			// Check if robot is still online
-			if !rsm.robotManager.IsRobotOnline(serialNumber) {
-				log.Printf("⚠️  로봇 오프라인 됨 - 자동 초기화 취소: %s", serialNumber)
+			if !rsm.robotManager.IsRobotOnline(robotID) {
+				log.Printf("⚠️  로봇 오프라인 됨 - 자동 초기화 취소: %s", robotID)
 				return
 			}
 
 			// Send init action
-			if err := rsm.sendActionToRobot(initAction, serialNumber); err != nil {
-				log.Printf("❌ 자동 위치 초기화 실패 - Serial: %s, Error: %v", serialNumber, err)
-				return
+			if initialize {
+				if err := rsm.sendInit(robotID, pose); err != nil {
+					log.Printf("❌ 자동 위치 초기화 실패 - Serial: %s, Error: %v", robotID, err)
+					return
+				}
+				log.Printf("✅ 자동 위치 초기화 완료 - Serial: %s", robotID)
 			}
 
-			log.Printf("✅ 자동 위치 초기화 완료 - Serial: %s", serialNumber)
-
 			// After successful init, request factsheet if enabled
 			if rsm.config.App.AutoFactsheetRequest {
-				if robot, exists := rsm.robotManager.GetRobotStatus(serialNumber); exists {
+				if robot, exists := rsm.robotManager.GetRobotStatus(robotID); exists {
 					// Wait a bit more for init to complete before requesting factsheet
 					time.Sleep(1 * time.Second)
 
-					log.Printf("📋 Factsheet 요청 시작 - Serial: %s", serialNumber)
-					if err := rsm.messageProcessor.SendFactsheetRequest(serialNumber, robot.Manufacturer); err != nil {
-						log.Printf("❌ Factsheet 요청 실패 - Serial: %s, Error: %v", serialNumber, err)
+					log.Printf("📋 Factsheet 요청 시작 - Serial: %s", robotID)
+					if err := rsm.messageProcessor.SendFactsheetRequest(robot.SerialNumber, robot.Manufacturer); err != nil {
+						log.Printf("❌ Factsheet 요청 실패 - Serial: %s, Error: %v", robotID, err)
 					} else {
-						log.Printf("✅ Factsheet 요청 완료 - Serial: %s", serialNumber)
+						log.Printf("✅ Factsheet 요청 완료 - Serial: %s", robotID)
 					}
 				}
 			}
@@ -84,10 +82,54 @@ func (rsm *RobotStatusMonitor) handleRobotStatusChange(serialNumber string, oldS
 	}
 }
 
+// autoInitPose returns the pose to initialize a robot coming online at, nil for the catalog "init" at the origin
+// With AutoInitUseLastPose the robot is initialized at its last localized position; a position older than
+// AutoInitMaxPoseAgeSec may no longer be where the robot stands, so the init is skipped (false)
+func (rsm *RobotStatusMonitor) autoInitPose(robotID string) (*Pose, bool) {
+	if !rsm.config.App.AutoInitUseLastPose {
+		return nil, true
+	}
+
+	position, exists := rsm.robotManager.GetLastKnownPosition(robotID)
+	if !exists {
+		log.Printf("⚠️  마지막 위치 없음 - 기본 위치로 초기화: %s", robotID)
+		return nil, true
+	}
+
+	age := time.Since(position.UpdatedAt)
+	maxAge := time.Duration(rsm.config.App.AutoInitMaxPoseAgeSec) * time.Second
+	if maxAge > 0 && age > maxAge {
+		log.Printf("⚠️  마지막 위치가 오래됨 - 자동 초기화 생략 - Robot: %s, 저장 시각: %s (%s 경과, 최대 %s)",
+			robotID, position.UpdatedAt.Format(time.RFC3339), age.Round(time.Second), maxAge)
+		return nil, false
+	}
+
+	pose := &Pose{
+		X:          position.X,
+		Y:          position.Y,
+		Theta:      position.Theta,
+		MapID:      position.MapID,
+		LastNodeID: position.LastNodeID,
+	}
+	log.Printf("📍 마지막 위치로 초기화 - Robot: %s, Pose: (%g, %g, %g) Map: %s, Node: %s (저장 시각: %s)",
+		robotID, pose.X, pose.Y, pose.Theta, pose.MapID, pose.LastNodeID, position.UpdatedAt.Format(time.RFC3339))
+	return pose, true
+}
+
+// sendInit initializes a robot at the given pose, or with the catalog "init" action when pose is nil
+// The pose is sent as typed initPosition parameters so map and node ids need no escaping
+func (rsm *RobotStatusMonitor) sendInit(robotID string, pose *Pose) error {
+	if pose != nil {
+		return rsm.messageProcessor.SendInitPosition(robotID, *pose)
+	}
+	return rsm.sendActionToRobot(&PLCActionMessage{Action: "init", SerialNumber: robotID}, robotID)
+}
+
 // sendActionToRobot is a helper method to send actions via message processor
-func (rsm *RobotStatusMonitor) sendActionToRobot(plcAction *PLCActionMessage, serialNumber string) error {
+func (rsm *RobotStatusMonitor) sendActionToRobot(plcAction *PLCActionMessage, robotID string) error {
 	// Use the message processor to send the action
-	return rsm.messageProcessor.sendActionToRobot(plcAction, serialNumber)
+	_, err := rsm.messageProcessor.sendActionToRobot(plcAction, robotID)
+	return err
 }
 
 // PrintStatusSummary prints a summary of all robot statuses
@@ -187,7 +229,7 @@ func (rsm *RobotStatusMonitor) CheckBatteryLevels() {
 	lowBatteryCount := 0
 	for serial, battery := range batteryStatuses {
 		// Use correct field names: BatteryCharge and Charging
-		if battery.BatteryCharge < 20.0 && !battery.Charging {
+		if battery.BatteryCharge < lowBatteryThreshold && !battery.Charging {
 			log.Printf("   🚨 배터리 부족: %s (%.1f%%)", serial, battery.BatteryCharge)
 			lowBatteryCount++
 		}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// StationRegistryFile represents the on-disk station registry
+type StationRegistryFile struct {
+	Stations map[string]NodePosition `json:"stations"`
+}
+
+// StationRegistry holds named poses (work cells) that order nodes can target
+type StationRegistry struct {
+	source   string
+	stations map[string]NodePosition
+}
+
+// LoadStationRegistry loads named stations from a JSON file, or returns an empty registry if path is empty
+func LoadStationRegistry(path string) (*StationRegistry, error) {
+	registry := &StationRegistry{
+		source:   "none",
+		stations: make(map[string]NodePosition),
+	}
+	if path == "" {
+		return registry, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read station registry: %w", err)
+	}
+
+	var file StationRegistryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse station registry %s: %w", path, err)
+	}
+
+	for name, position := range file.Stations {
+		if name == "" || strings.ContainsAny(name, "@:/") {
+			return nil, fmt.Errorf("station registry %s: invalid station name '%s'", path, name)
+		}
+		if position.MapID == "" {
+			return nil, fmt.Errorf("station registry %s: station '%s' requires mapId", path, name)
+		}
+		if position.AllowedDeviationXY < 0 || position.AllowedDeviationTheta < 0 {
+			return nil, fmt.Errorf("station registry %s: station '%s' has negative allowed deviation", path, name)
+		}
+		registry.stations[name] = position
+	}
+
+	registry.source = path
+	return registry, nil
+}
+
+// Source returns where the registry was loaded from
+func (sr *StationRegistry) Source() string {
+	return sr.source
+}
+
+// Len returns the number of registered stations
+func (sr *StationRegistry) Len() int {
+	return len(sr.stations)
+}
+
+// GetStation returns the pose of a named station
+func (sr *StationRegistry) GetStation(name string) (NodePosition, error) {
+	position, exists := sr.stations[name]
+	if !exists {
+		return NodePosition{}, fmt.Errorf("unknown station: %s", name)
+	}
+	return position, nil
+}
+
+// GetStationNames returns all station names sorted alphabetically
+func (sr *StationRegistry) GetStationNames() []string {
+	names := make([]string, 0, len(sr.stations))
+	for name := range sr.stations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// splitStationSuffix splits "I:inference1@stationB" into the command and the target station
+func splitStationSuffix(action string) (string, string) {
+	index := strings.LastIndex(action, "@")
+	if index < 0 {
+		return action, ""
+	}
+	return strings.TrimSpace(action[:index]), strings.TrimSpace(action[index+1:])
+}